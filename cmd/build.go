@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"ginana-blog/internal/builder"
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/wire"
+	"os"
+)
+
+// runBuild 执行 build 子命令，将公开页面生成为静态站点
+//
+//	ginana build [-o DIR]
+func runBuild(args []string) int {
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	out := fs.String("o", "public", "输出目录，已存在的同名文件会被覆盖")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	app, closeFunc, err := wire.InitApp()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer closeFunc()
+	options, err := app.Service().GetSiteOptions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	list, err := app.Service().GetSitemapArticles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	articles := make([]string, 0, len(list))
+	for _, a := range list {
+		articles = append(articles, a.Link())
+	}
+	report, err := builder.Run(app.Server.Handler, &builder.Options{
+		Output:    *out,
+		StaticDir: config.Global().StaticDir,
+		SiteURL:   options.SiteURL,
+		Articles:  articles,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "生成失败：", err)
+		return 1
+	}
+	for _, v := range report.Failed {
+		fmt.Println("未生成：", v)
+	}
+	for _, v := range report.Skipped {
+		fmt.Println("已跳过：", v)
+	}
+	fmt.Printf("已生成到 %s：页面 %d 个，跳转页 %d 个，静态文件 %d 个\n",
+		*out, report.Pages, report.Redirects, report.Assets)
+	return 0
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/exporter"
+	"ginana-blog/internal/wire"
+	"os"
+	"time"
+)
+
+// runExport 执行 export 子命令，将全部内容导出为 zip
+//
+//	ginana export [-o FILE]
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := fs.String("o", "", "输出文件，默认为当前目录下的 ginana-export-时间.zip")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("ginana-export-%s.zip", time.Now().Format("20060102-150405"))
+	}
+	app, closeFunc, err := wire.InitApp()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer closeFunc()
+	f, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	err = exporter.Run(app.Service(), f, config.Global().StaticDir)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		_ = os.Remove(*out)
+		fmt.Fprintln(os.Stderr, "导出失败：", err)
+		return 1
+	}
+	fmt.Println("已导出到", *out)
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/importer"
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/wire"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// runImport 执行 import 子命令，返回进程退出码
+//
+//	ginana import -format wxr|hexo|hugo|ghost [-site URL] [-dry-run] PATH
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "导入格式："+strings.Join(model.ImportFormats, "、"))
+	site := fs.String("site", "", "原站地址，本地找不到的站内图片从原站下载")
+	dryRun := fs.Bool("dry-run", false, "只输出导入报告，不写入数据也不保存图片")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: import -format 格式 [-site 原站地址] [-dry-run] 导出文件或目录")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || !model.IsImportFormat(*format) {
+		fs.Usage()
+		return 2
+	}
+	app, closeFunc, err := wire.InitApp()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer closeFunc()
+	report, err := importer.Run(app.Service(), &importer.Options{
+		Format:    *format,
+		Path:      fs.Arg(0),
+		StaticDir: config.Global().StaticDir,
+		SiteURL:   *site,
+		DryRun:    *dryRun,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "导入失败：", err)
+		return 1
+	}
+	printImportReport(report)
+	return 0
+}
+
+func printImportReport(r *model.ImportReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "标题\t来源\t新链接\t评论\t说明")
+	for _, item := range r.Items {
+		link, note := item.Link, item.Note
+		if item.Skipped {
+			link, note = "-", "跳过："+note
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", item.Title, item.Source, link, item.Comments, note)
+	}
+	_ = w.Flush()
+	for _, v := range r.Warnings {
+		fmt.Println("警告：", v)
+	}
+	if r.DryRun {
+		fmt.Println("试运行，未写入任何数据")
+	}
+	fmt.Printf("文章 %d 篇，跳过 %d 篇，新标签 %d 个，新用户 %d 个，评论 %d 条，图片 %d 张，跳转 %d 条\n",
+		r.Articles, r.Skipped, r.Tags, r.Users, r.Comments, r.Images, r.Redirects)
+}
@@ -1,18 +1,62 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	_ "ginana-blog/docs"
 	"ginana-blog/internal/config"
-	"ginana-blog/internal/wire"
 	"github.com/griffin702/ginana/library/conf/paladin"
 	"github.com/griffin702/ginana/library/log"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 )
 
+// 子命令，返回进程退出码
+var commands = map[string]func(args []string) int{
+	"serve":   runServe,
+	"user":    runUser,
+	"role":    runRole,
+	"policy":  runPolicy,
+	"cache":   runCache,
+	"migrate": runMigrate,
+	"reindex": runReindex,
+	"config":  runConfig,
+	"import":  runImport,
+	"export":  runExport,
+	"build":   runBuild,
+	"backup":  runBackup,
+	"restore": runRestore,
+	"setup":   runSetup,
+}
+
+const usage = `用法: ginana [配置参数] [命令] [参数]
+
+命令:
+  serve                                 启动HTTP服务（默认），尚未安装时读取配置目录下的 seed.toml
+                                        SIGTERM 优雅退出，SIGHUP 重新载入 http.toml 与模板，
+                                        SIGUSR2 交接监听套接字重启，用于升级程序
+  setup [FILE]                          以种子文件完成首次安装，默认为配置目录下的 seed.toml
+  user create [-password P] [-nickname N] [-email E] [-role R] 用户名
+                                        创建已认证的用户，未指定密码时随机生成
+  user reset-password [-password P] 用户名
+                                        重置密码，未指定密码时随机生成
+  user disable|enable 用户名            禁用或启用账号
+  role grant 用户名 角色名              为用户添加角色
+  policy sync                           将 polices.json 中缺少的规则写入数据库
+  cache flush                           清空缓存
+  migrate                               补建数据表与字段，并为重复的文章URL名称追加序号
+  reindex                               重新计算文章字数、阅读时长与大纲
+  config check                          校验配置文件
+  import / export / build               导入、导出、生成静态站点，-h 查看参数
+  backup [-o FILE] / restore FILE       备份与恢复
+
+配置参数:
+  每个配置项都可以在命令之前以参数覆盖，如 -mysql.dbPwd=secret、-server.addr=:8000，
+  也可以使用环境变量，如 GINANA_MYSQL_DBPWD、GINANA_SERVER_ADDR。
+  优先级从高到低为：命令行参数、环境变量、配置文件。
+  配置目录默认为 ../configs，可以通过 -configPath 或 GINANA_CONFIGPATH 指定。
+  ginana -h 列出全部配置参数
+`
+
 // @title GiNana
 // @version 1.0.0
 // @description 基于GiNana的个人网站项目，默认端口：8000
@@ -21,40 +65,42 @@ import (
 // @license.name MIT License
 // @license.url
 func main() {
+	fs := flag.NewFlagSet("ginana", flag.ContinueOnError)
+	config.RegisterFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage, "\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+	name, args := "serve", fs.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	run, ok := commands[name]
+	if !ok {
+		if name == "help" {
+			fmt.Print(usage)
+			os.Exit(0)
+		}
+		fmt.Fprintf(os.Stderr, "未知命令：%s\n\n%s", name, usage)
+		os.Exit(2)
+	}
 	closeLog := log.Init()
-	log.Info("GiNana App Start")
 	cfg, err := config.GetBaseConfig()
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 	if err := paladin.Init(cfg.ConfigIsLocal, cfg.ConfigPath); err != nil {
-		panic(err)
-	}
-	app, closeFunc, err := wire.InitApp()
-	if err != nil {
-		panic(err)
-	}
-	go func() {
-		ch := make(chan os.Signal, 1)
-		signal.Notify(ch, syscall.SIGHUP, syscall.SIGINT, syscall.SIGKILL, syscall.SIGTERM, syscall.SIGQUIT)
-		for {
-			s := <-ch
-			log.Infof("get a signal %s", s.String())
-			switch s {
-			case syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT:
-				log.Info("GiNana App Exit")
-				time.Sleep(time.Second)
-				closeFunc()
-				closeLog()
-				return
-			case syscall.SIGHUP:
-			default:
-				return
-			}
-		}
-	}()
-	err = app.Server.ListenAndServe()
-	if err != nil && err != http.ErrServerClosed {
-		log.Errorf(err.Error())
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
+	code := run(args)
+	closeLog()
+	os.Exit(code)
 }
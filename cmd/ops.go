@@ -0,0 +1,243 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/service"
+	"ginana-blog/internal/wire"
+	"github.com/griffin702/ginana/library/conf/paladin"
+	"os"
+	"strings"
+)
+
+// withService 初始化与 serve 相同的依赖但不监听端口，执行 fn 后释放资源
+func withService(fn func(svc service.Service) error) int {
+	app, closeFunc, err := wire.InitApp()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer closeFunc()
+	if err = fn(app.Service()); err != nil {
+		fmt.Fprintln(os.Stderr, "执行失败：", err)
+		return 1
+	}
+	return 0
+}
+
+// subcommand 取出子命令的动作名，缺少时输出用法
+func subcommand(name string, args []string, actions ...string) (action string, rest []string, ok bool) {
+	if len(args) > 0 {
+		for _, a := range actions {
+			if args[0] == a {
+				return a, args[1:], true
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "用法: %s %s\n", name, strings.Join(actions, "|"))
+	return "", nil, false
+}
+
+func randomPassword() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// runUser 执行 user 子命令，用于创建账号与找回被锁定的管理员
+//
+//	ginana user create [-password P] [-nickname N] [-email E] [-role R] USERNAME
+//	ginana user reset-password [-password P] USERNAME
+//	ginana user disable|enable USERNAME
+func runUser(args []string) int {
+	action, args, ok := subcommand("user", args, "create", "reset-password", "disable", "enable")
+	if !ok {
+		return 2
+	}
+	fs := flag.NewFlagSet("user "+action, flag.ContinueOnError)
+	password, nickname, email, role := new(string), new(string), new(string), new(string)
+	switch action {
+	case "create":
+		password = fs.String("password", "", "密码，未指定时随机生成并输出")
+		nickname = fs.String("nickname", "", "昵称，默认同用户名")
+		email = fs.String("email", "", "邮箱")
+		role = fs.String("role", "", "角色名称")
+	case "reset-password":
+		password = fs.String("password", "", "密码，未指定时随机生成并输出")
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: user %s [参数] 用户名\n", action)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	username := fs.Arg(0)
+	generated := false
+	if action == "create" || action == "reset-password" {
+		if *password == "" {
+			*password, generated = randomPassword(), true
+		} else if len(*password) < 6 {
+			fmt.Fprintln(os.Stderr, "密码不能少于6位")
+			return 2
+		}
+	}
+	return withService(func(svc service.Service) (err error) {
+		switch action {
+		case "create":
+			req := &model.CreateUserReq{
+				Username: username,
+				Password: *password,
+				Nickname: *nickname,
+				Email:    *email,
+				IsAuth:   true,
+			}
+			if req.Nickname == "" {
+				req.Nickname = username
+			}
+			if *role != "" {
+				r, err := svc.GetRoleByName(*role)
+				if err != nil {
+					return fmt.Errorf("角色 %s 不存在", *role)
+				}
+				req.IDs = append(req.IDs, r.ID)
+			}
+			if _, err = svc.CreateUser(req); err != nil {
+				return
+			}
+			fmt.Println("已创建用户", username)
+		case "reset-password":
+			if _, err = svc.ResetPassword(username, *password); err != nil {
+				return
+			}
+			fmt.Println("已重置密码", username)
+		case "disable", "enable":
+			if _, err = svc.SetUserAuth(username, action == "enable"); err != nil {
+				return
+			}
+			fmt.Printf("已%s用户 %s\n", map[string]string{"disable": "禁用", "enable": "启用"}[action], username)
+			return
+		}
+		if generated {
+			fmt.Println("密码：", *password)
+		}
+		return
+	})
+}
+
+// runRole 执行 role 子命令
+//
+//	ginana role grant USERNAME ROLE
+func runRole(args []string) int {
+	_, args, ok := subcommand("role", args, "grant")
+	if !ok {
+		return 2
+	}
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "用法: role grant 用户名 角色名")
+		return 2
+	}
+	return withService(func(svc service.Service) (err error) {
+		if err = svc.GrantRole(args[0], args[1]); err != nil {
+			return
+		}
+		fmt.Printf("已为 %s 添加角色 %s\n", args[0], args[1])
+		return
+	})
+}
+
+// runPolicy 执行 policy 子命令，升级后同步新增路由的规则
+//
+//	ginana policy sync
+func runPolicy(args []string) int {
+	if _, _, ok := subcommand("policy", args, "sync"); !ok {
+		return 2
+	}
+	var polices []*model.Policy
+	if err := paladin.Get("polices.json").UnmarshalJSON(&polices); err != nil {
+		fmt.Fprintln(os.Stderr, "读取 polices.json 失败：", err)
+		return 1
+	}
+	return withService(func(svc service.Service) (err error) {
+		created, err := svc.SyncPolices(polices)
+		for _, p := range created {
+			fmt.Printf("新增规则 %s %s %s\n", p.Name, p.Method, p.Router)
+		}
+		if err == nil {
+			fmt.Printf("共新增 %d 条规则，可在角色管理中分配\n", len(created))
+		}
+		return
+	})
+}
+
+// runCache 执行 cache 子命令
+//
+//	ginana cache flush
+func runCache(args []string) int {
+	if _, _, ok := subcommand("cache", args, "flush"); !ok {
+		return 2
+	}
+	return withService(func(svc service.Service) (err error) {
+		if err = svc.FlushCache(); err == nil {
+			fmt.Println("已清空缓存")
+		}
+		return
+	})
+}
+
+// runMigrate 执行 migrate 子命令
+//
+//	ginana migrate
+func runMigrate(args []string) int {
+	return withService(func(svc service.Service) (err error) {
+		tables, err := svc.Migrate()
+		if err != nil {
+			return
+		}
+		fmt.Println("已同步数据表：", strings.Join(tables, ", "))
+		renamed, err := svc.FixDuplicateSlugs()
+		for _, article := range renamed {
+			fmt.Printf("文章 %d 的URL名称重复，已改为 %s\n", article.ID, article.Urlname)
+		}
+		return
+	})
+}
+
+// runReindex 执行 reindex 子命令
+//
+//	ginana reindex
+func runReindex(args []string) int {
+	return withService(func(svc service.Service) (err error) {
+		count, err := svc.Reindex()
+		if err == nil {
+			fmt.Printf("已重建 %d 篇文章的索引数据\n", count)
+		}
+		return
+	})
+}
+
+// runConfig 执行 config 子命令，只读取配置文件，不连接数据库
+//
+//	ginana config check
+func runConfig(args []string) int {
+	if _, _, ok := subcommand("config", args, "check"); !ok {
+		return 2
+	}
+	problems := config.Check()
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	fmt.Println("配置检查通过")
+	return 0
+}
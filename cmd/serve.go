@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"ginana-blog/internal/backup"
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/server"
+	"ginana-blog/internal/wire"
+	"github.com/griffin702/ginana/library/log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// runServe 执行 serve 子命令，启动HTTP服务直到收到退出信号
+//
+//	SIGINT、SIGTERM、SIGQUIT  停止接受新请求，等待进行中的请求处理完毕后退出
+//	SIGHUP                    重新载入 http.toml 与模板
+//	SIGUSR2                   交接重启：启动新版本的程序并交出监听套接字，新进程就绪后本进程退出
+//
+//	ginana [serve]
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	log.Info("GiNana App Start")
+	app, closeFunc, err := wire.InitApp()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err = applySeed(app.Service()); err != nil {
+		log.Errorf("种子文件安装失败：%v", err)
+	}
+	cfg := config.Global()
+	l, inherited, err := server.Listen(app.Server.Addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		closeFunc()
+		return 1
+	}
+	g := server.NewGraceful(l, app.Server)
+	stopBackup := backup.Schedule(app.Service(), cfg)
+	// 先停止接受请求并等待进行中的请求，再停止定时备份，最后写回计数并关闭数据库
+	shutdown := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), server.ShutdownTimeout(cfg))
+		defer cancel()
+		if err := g.Shutdown(ctx); err != nil {
+			log.Errorf("httpServer.Shutdown error(%v)", err)
+		}
+		stopBackup()
+		closeFunc()
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, append([]os.Signal{syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT}, handoverSignals...)...)
+	served := make(chan error, 1)
+	go func() {
+		served <- g.Serve()
+	}()
+	log.Printf("HTTP服务已启动 [ http://%s ]", l.Addr())
+	if inherited {
+		notifyParent()
+	}
+	for {
+		select {
+		case err = <-served:
+			log.Errorf(err.Error())
+			shutdown()
+			return 1
+		case s := <-ch:
+			log.Infof("get a signal %s", s.String())
+			switch s {
+			case syscall.SIGHUP:
+				if err = server.Reload(g, cfg); err != nil {
+					log.Errorf("reload error(%v)", err)
+					continue
+				}
+				log.Info("已重新载入 http.toml 与模板")
+			case syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT:
+				log.Info("GiNana App Exit")
+				shutdown()
+				<-served
+				return 0
+			default:
+				p, err := server.Handover(l)
+				if err != nil {
+					log.Errorf("handover error(%v)", err)
+					continue
+				}
+				log.Infof("已启动新进程 %d，就绪后本进程退出", p.Pid)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/service"
+	"github.com/BurntSushi/toml"
+	"github.com/griffin702/ginana/library/log"
+	"os"
+	"path/filepath"
+)
+
+// 默认种子文件，位于配置目录下
+const seedFile = "seed.toml"
+
+// readSeed 读取种子文件，文件不存在时返回 nil
+func readSeed(file string) (req *model.SetupReq, err error) {
+	if _, err = os.Stat(file); os.IsNotExist(err) {
+		return nil, nil
+	}
+	req = new(model.SetupReq)
+	if _, err = toml.DecodeFile(file, req); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return
+}
+
+// applySeed 尚未安装且存在种子文件时，以种子文件完成安装，用于自动化部署
+func applySeed(svc service.Service) (err error) {
+	if !svc.NeedSetup() {
+		return
+	}
+	file := filepath.Join(config.Global().ConfigPath, seedFile)
+	req, err := readSeed(file)
+	if err != nil || req == nil {
+		return
+	}
+	if _, err = svc.Setup(req); err != nil {
+		return
+	}
+	log.Infof("已根据种子文件 %s 完成安装，管理员：%s", file, req.Username)
+	return
+}
+
+// runSetup 执行 setup 子命令，以种子文件完成首次安装，不启动HTTP服务
+//
+//	ginana setup [FILE]
+func runSetup(args []string) int {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "用法: setup [种子文件]")
+		return 2
+	}
+	file := filepath.Join(config.Global().ConfigPath, seedFile)
+	if len(args) == 1 {
+		file = args[0]
+	}
+	req, err := readSeed(file)
+	if err == nil && req == nil {
+		err = fmt.Errorf("种子文件不存在：%s", file)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return withService(func(svc service.Service) (err error) {
+		if !svc.NeedSetup() {
+			return errors.New("已存在管理员，无需安装")
+		}
+		if _, err = svc.Setup(req); err != nil {
+			return
+		}
+		fmt.Println("安装完成，管理员：", req.Username)
+		return
+	})
+}
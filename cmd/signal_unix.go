@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// 触发交接重启的信号
+var handoverSignals = []os.Signal{syscall.SIGUSR2}
+
+// notifyParent 交接重启的新进程就绪后通知旧进程优雅退出
+func notifyParent() {
+	_ = syscall.Kill(os.Getppid(), syscall.SIGTERM)
+}
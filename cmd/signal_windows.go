@@ -0,0 +1,8 @@
+package main
+
+import "os"
+
+// Windows 不支持交接监听套接字
+var handoverSignals []os.Signal
+
+func notifyParent() {}
@@ -4,7 +4,9 @@ go 1.13
 
 require (
 	github.com/BurntSushi/toml v0.3.1
+	github.com/alecthomas/chroma v0.7.2-0.20200305040604-4f3623dce67a
 	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751
+	github.com/bradfitz/gomemcache v0.0.0-20190329173943-551aad21a668
 	github.com/casbin/casbin/v2 v2.2.2
 	github.com/google/wire v0.4.0
 	github.com/griffin702/ginana v1.6.9
@@ -12,11 +14,15 @@ require (
 	github.com/jinzhu/gorm v1.9.11
 	github.com/kataras/golog v0.0.10
 	github.com/kataras/iris/v12 v12.1.8
+	github.com/microcosm-cc/bluemonday v1.0.2
+	github.com/mozillazg/go-pinyin v0.21.0
 	github.com/nats-io/nats-server/v2 v2.1.2 // indirect
 	github.com/philchia/agollo v2.1.0+incompatible // indirect
 	github.com/smartystreets/goconvey v0.0.0-20190731233626-505e41936337
 	github.com/swaggo/swag v1.6.3
-	golang.org/x/net v0.0.0-20200324143707-d3edc9973b7e // indirect
+	github.com/yuin/goldmark v1.2.1
+	github.com/yuin/goldmark-highlighting v0.0.0-20200307114337-60d527fdb691
+	golang.org/x/net v0.0.0-20200324143707-d3edc9973b7e
 	golang.org/x/sys v0.0.0-20200420163511-1957bb5e6d1f // indirect
-	gopkg.in/yaml.v2 v2.2.8 // indirect
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,350 @@
+// Package backup 全量备份与恢复：全部数据表以与数据库无关的 JSON 保存，连同上传目录
+// 打包为单个 zip，manifest.json 记录每个文件的 SHA-256 校验值，恢复前先校验
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/service"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	manifestName = "manifest.json"
+	version      = 1 // 备份格式版本
+)
+
+// Manifest 备份清单
+type Manifest struct {
+	Version    int               `json:"version"`
+	App        string            `json:"app"`
+	AppVersion string            `json:"app_version"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Tables     map[string]int    `json:"tables"` // 表名 -> 行数
+	Files      map[string]string `json:"files"`  // 压缩包内文件 -> SHA-256
+}
+
+// Create 读取全部数据表并写入备份
+func Create(svc service.Service, w io.Writer, staticDir string) (m *Manifest, err error) {
+	tables, err := svc.BackupTables()
+	if err != nil {
+		return
+	}
+	return Write(w, tables, staticDir)
+}
+
+// Write 将数据表与上传目录写入 zip，数据表保存为 data/表名.json，上传文件保存在 upload 下
+func Write(w io.Writer, tables []*model.BackupTable, staticDir string) (m *Manifest, err error) {
+	cfg := config.Global()
+	b := &writer{
+		zw: zip.NewWriter(w),
+		m: &Manifest{
+			Version:    version,
+			App:        cfg.AppName,
+			AppVersion: cfg.Version,
+			CreatedAt:  time.Now(),
+			Tables:     make(map[string]int),
+			Files:      make(map[string]string),
+		},
+	}
+	for _, t := range tables {
+		var data []byte
+		if data, err = json.Marshal(t); err != nil {
+			return
+		}
+		if err = b.write("data/"+t.Name+".json", time.Now(), bytes.NewReader(data)); err != nil {
+			return
+		}
+		b.m.Tables[t.Name] = len(t.Rows)
+	}
+	_, dir := config.StaticRoot(staticDir)
+	if err = b.writeDir(filepath.Join(dir, "upload"), "upload"); err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(b.m, "", "  ")
+	if err != nil {
+		return
+	}
+	if err = b.write(manifestName, time.Now(), bytes.NewReader(data)); err != nil {
+		return
+	}
+	return b.m, b.zw.Close()
+}
+
+type writer struct {
+	zw *zip.Writer
+	m  *Manifest
+}
+
+// write 写入文件并记录校验值，清单本身不记录
+func (b *writer) write(name string, modified time.Time, r io.Reader) (err error) {
+	fh := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	fh.SetModTime(modified)
+	w, err := b.zw.CreateHeader(fh)
+	if err != nil {
+		return
+	}
+	h := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(w, h), r); err != nil {
+		return
+	}
+	if name != manifestName {
+		b.m.Files[name] = hex.EncodeToString(h.Sum(nil))
+	}
+	return
+}
+
+func (b *writer) writeDir(dir, prefix string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(dir, func(file string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return b.write(path.Join(prefix, filepath.ToSlash(rel)), fi.ModTime(), f)
+	})
+}
+
+// Restore 校验备份后恢复：清空并写入全部数据表，上传文件覆盖到静态目录，
+// 静态目录中备份里没有的文件保留
+func Restore(svc service.Service, file, staticDir string) (m *Manifest, err error) {
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+	if m, err = Verify(&r.Reader); err != nil {
+		return
+	}
+	var tables []*model.BackupTable
+	for _, f := range r.File {
+		if !strings.HasPrefix(f.Name, "data/") {
+			continue
+		}
+		t := new(model.BackupTable)
+		if err = decode(f, t); err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Name, err)
+		}
+		tables = append(tables, t)
+	}
+	if err = svc.RestoreTables(tables); err != nil {
+		return
+	}
+	_, dir := config.StaticRoot(staticDir)
+	dest, err := filepath.Abs(filepath.Join(dir, "upload"))
+	if err != nil {
+		return
+	}
+	for _, f := range r.File {
+		if !strings.HasPrefix(f.Name, "upload/") || f.FileInfo().IsDir() {
+			continue
+		}
+		if err = extract(f, filepath.Join(dest, filepath.FromSlash(strings.TrimPrefix(f.Name, "upload/")))); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Verify 读取清单并校验全部文件，缺失、多余或校验值不符均视为损坏
+func Verify(r *zip.Reader) (m *Manifest, err error) {
+	files := make(map[string]*zip.File)
+	for _, f := range r.File {
+		if !f.FileInfo().IsDir() {
+			files[f.Name] = f
+		}
+	}
+	mf, ok := files[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("备份中缺少 %s", manifestName)
+	}
+	m = new(Manifest)
+	if err = decode(mf, m); err != nil {
+		return nil, fmt.Errorf("%s: %v", manifestName, err)
+	}
+	if m.Version > version {
+		return nil, fmt.Errorf("不支持的备份格式版本：%d", m.Version)
+	}
+	for name, f := range files {
+		if name == manifestName {
+			continue
+		}
+		if clean := path.Clean(name); clean != name || strings.HasPrefix(clean, "../") || path.IsAbs(clean) {
+			return nil, fmt.Errorf("备份中的路径不合法：%s", name)
+		}
+		sum, ok := m.Files[name]
+		if !ok {
+			return nil, fmt.Errorf("文件不在清单中：%s", name)
+		}
+		if err = check(f, sum); err != nil {
+			return nil, err
+		}
+	}
+	for name := range m.Files {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("备份中缺少文件：%s", name)
+		}
+	}
+	return
+}
+
+func check(f *zip.File, sum string) (err error) {
+	rc, err := f.Open()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, rc); err != nil {
+		return fmt.Errorf("%s: %v", f.Name, err)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != sum {
+		return fmt.Errorf("校验值不符：%s", f.Name)
+	}
+	return
+}
+
+// decode 解码 JSON，数字保留为 json.Number 以免大整数丢失精度
+func decode(f *zip.File, v interface{}) (err error) {
+	rc, err := f.Open()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+	dec := json.NewDecoder(rc)
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func extract(f *zip.File, name string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+	w, err := os.Create(name)
+	if err != nil {
+		return
+	}
+	if _, err = io.Copy(w, rc); err != nil {
+		w.Close()
+		return
+	}
+	if err = w.Close(); err != nil {
+		return
+	}
+	return os.Chtimes(name, f.Modified, f.Modified)
+}
+
+// File 备份目录中的备份文件
+type File struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+const (
+	filePrefix = "ginana-backup-"
+	fileLayout = "20060102-150405"
+)
+
+// FileName 按时间生成的备份文件名
+func FileName(t time.Time) string {
+	return filePrefix + t.Format(fileLayout) + ".zip"
+}
+
+// List 列出备份目录中的备份，最新的在前
+func List(dir string) (list []*File, err error) {
+	matches, err := filepath.Glob(filepath.Join(dir, filePrefix+"*.zip"))
+	if err != nil {
+		return
+	}
+	for _, file := range matches {
+		fi, err := os.Stat(file)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		list = append(list, &File{Name: fi.Name(), Size: fi.Size(), ModTime: fi.ModTime()})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name > list[j].Name })
+	return
+}
+
+// Save 在备份目录中生成一份备份，并只保留最近 keep 份，keep 小于1时不删除
+func Save(svc service.Service, dir, staticDir string, keep int) (file string, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	file = filepath.Join(dir, FileName(time.Now()))
+	tmp := file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return
+	}
+	_, err = Create(svc, f, staticDir)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmp, file)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return file, Rotate(dir, keep)
+}
+
+// Rotate 删除超出保留数量的旧备份
+func Rotate(dir string, keep int) (err error) {
+	if keep < 1 {
+		return
+	}
+	list, err := List(dir)
+	if err != nil {
+		return
+	}
+	for i := keep; i < len(list); i++ {
+		if err = os.Remove(filepath.Join(dir, list[i].Name)); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// SizeText 便于阅读的文件大小
+func (f *File) SizeText() string {
+	size := float64(f.Size)
+	for _, unit := range []string{"B", "KB", "MB"} {
+		if size < 1024 {
+			return fmt.Sprintf("%.1f %s", size, unit)
+		}
+		size /= 1024
+	}
+	return fmt.Sprintf("%.1f GB", size)
+}
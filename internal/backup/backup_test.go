@@ -0,0 +1,109 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"ginana-blog/internal/model"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	upload := filepath.Join(dir, "upload", "image")
+	if err = os.MkdirAll(upload, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(upload, "a.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tables := []*model.BackupTable{
+		{Name: "users", Rows: []map[string]interface{}{{"id": int64(1), "username": "admin", "is_auth": true}}},
+		{Name: "article_tags", Rows: []map[string]interface{}{{"article_id": int64(9007199254740993), "tag_id": int64(2)}}},
+	}
+	var buf bytes.Buffer
+	m, err := Write(&buf, tables, "static:"+dir)
+
+	Convey("Write", t, func() {
+		So(err, ShouldBeNil)
+		So(m.Tables, ShouldResemble, map[string]int{"users": 1, "article_tags": 1})
+		So(m.Files, ShouldContainKey, "data/users.json")
+		So(m.Files, ShouldContainKey, "upload/image/a.png")
+	})
+
+	Convey("Verify", t, func() {
+		r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		So(err, ShouldBeNil)
+		got, err := Verify(r)
+		So(err, ShouldBeNil)
+		So(got.Files, ShouldResemble, m.Files)
+		for _, f := range r.File {
+			if f.Name == "data/article_tags.json" {
+				table := new(model.BackupTable)
+				So(decode(f, table), ShouldBeNil)
+				So(table.Rows[0]["article_id"], ShouldEqual, json.Number("9007199254740993"))
+			}
+		}
+	})
+
+	Convey("Verify tampered", t, func() {
+		var tampered bytes.Buffer
+		zw := zip.NewWriter(&tampered)
+		r, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		for _, f := range r.File {
+			w, _ := zw.Create(f.Name)
+			if f.Name == "upload/image/a.png" {
+				_, _ = w.Write([]byte("gif"))
+				continue
+			}
+			rc, _ := f.Open()
+			b, _ := ioutil.ReadAll(rc)
+			rc.Close()
+			_, _ = w.Write(b)
+		}
+		So(zw.Close(), ShouldBeNil)
+		r, err := zip.NewReader(bytes.NewReader(tampered.Bytes()), int64(tampered.Len()))
+		So(err, ShouldBeNil)
+		_, err = Verify(r)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "upload/image/a.png")
+	})
+}
+
+func TestRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		name := filepath.Join(dir, FileName(now.AddDate(0, 0, i)))
+		if err = ioutil.WriteFile(name, []byte("zip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "other.zip"), []byte("zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("Rotate", t, func() {
+		So(Rotate(dir, 2), ShouldBeNil)
+		list, err := List(dir)
+		So(err, ShouldBeNil)
+		So(len(list), ShouldEqual, 2)
+		So(list[0].Name, ShouldEqual, "ginana-backup-20200105-000000.zip")
+		So(list[1].Name, ShouldEqual, "ginana-backup-20200104-000000.zip")
+		_, err = os.Stat(filepath.Join(dir, "other.zip"))
+		So(err, ShouldBeNil)
+	})
+}
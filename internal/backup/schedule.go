@@ -0,0 +1,40 @@
+package backup
+
+import (
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/service"
+	"github.com/griffin702/ginana/library/log"
+	"time"
+)
+
+// Schedule 按 BackupInterval 定时备份到 BackupDir 并轮换，返回停止函数，
+// 停止时等待进行中的备份完成
+func Schedule(svc service.Service, cfg *config.Config) (stop func()) {
+	interval := time.Duration(cfg.BackupInterval)
+	if interval <= 0 || cfg.BackupDir == "" {
+		return func() {}
+	}
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				file, err := Save(svc, cfg.BackupDir, cfg.StaticDir, cfg.BackupKeep)
+				if err != nil {
+					log.Errorf("backup error(%v)", err)
+					continue
+				}
+				log.Infof("backup saved to %s", file)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
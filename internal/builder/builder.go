@@ -0,0 +1,315 @@
+// Package builder 通过站点自身的 Iris 处理器渲染全部公开页面，生成可部署到
+// 静态托管的目录：从首页、列表页、订阅、站点地图与给定的文章列表出发抓取站内链接，
+// 文章只生成列表中的已发布公开文章，分页地址改写为 /page/N/ 形式，静态资源目录整体复制。
+// 评论、点赞、搜索等需要后端的功能不可用
+package builder
+
+import (
+	"fmt"
+	"ginana-blog/internal/config"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 浏览计数与访问统计会忽略带 bot 标识的请求
+const userAgent = "Mozilla/5.0 (compatible; GiNanaBuilder/1.0; static site bot)"
+
+// 抓取入口，其余页面通过链接发现
+var seeds = []string{
+	"/", "/about.html", "/life.html", "/category.html", "/mood.html", "/links.html",
+	"/album.html", "/archives", "/feed.xml", "/sitemap.xml", "/favicon.ico",
+}
+
+// 不生成静态页面的路径前缀
+var excludes = []string{"/admin", "/api", "/public", "/swagger"}
+
+// 文章地址的前缀，只有 Options.Articles 中的文章才会生成
+var articlePrefixes = []string{"/article/", "/s/"}
+
+var (
+	attrLink = regexp.MustCompile(`(?i)\b(href|src)\s*=\s*"([^"]*)"`)
+	xmlLink  = regexp.MustCompile(`<(?:loc|link|guid)[^>]*>\s*([^<\s]+)\s*</`)
+)
+
+// Options 生成选项
+type Options struct {
+	Output    string   // 输出目录，已存在的同名文件会被覆盖
+	StaticDir string   // 静态文件配置，格式同 Config.StaticDir
+	SiteURL   string   // 站点地址，订阅与站点地图中的同站绝对地址按站内链接处理
+	Articles  []string // 已发布的公开文章地址，其余文章链接不抓取
+}
+
+// Report 生成结果
+type Report struct {
+	Pages     int
+	Redirects int
+	Assets    int
+	Failed    []string // 返回错误状态码而未生成的地址
+	Skipped   []string // 声明 noindex 而未生成的地址，如加锁或未发布的文章
+}
+
+// Run 抓取并写出全部页面，随后复制静态资源
+func Run(h http.Handler, opt *Options) (report *Report, err error) {
+	b := &builder{
+		handler:  h,
+		out:      opt.Output,
+		report:   new(Report),
+		visited:  make(map[string]bool),
+		articles: make(map[string]bool),
+	}
+	for _, link := range opt.Articles {
+		b.articles[unescapePath(link)] = true
+	}
+	if u, e := url.Parse(opt.SiteURL); e == nil && u.Host != "" {
+		b.host = u.Host
+	}
+	for _, v := range strings.Fields(opt.StaticDir) {
+		if i := strings.Index(v, ":"); i > 0 {
+			b.statics = append(b.statics, [2]string{"/" + strings.Trim(v[:i], "/"), v[i+1:]})
+		}
+	}
+	if len(b.statics) == 0 {
+		prefix, dir := config.StaticRoot(opt.StaticDir)
+		b.statics = append(b.statics, [2]string{prefix, dir})
+	}
+	if err = os.MkdirAll(b.out, 0755); err != nil {
+		return
+	}
+	for _, s := range seeds {
+		b.enqueue(s)
+	}
+	for _, link := range opt.Articles {
+		b.enqueue(link)
+	}
+	for len(b.queue) > 0 {
+		link := b.queue[0]
+		b.queue = b.queue[1:]
+		if err = b.page(link); err != nil {
+			return
+		}
+	}
+	for _, s := range b.statics {
+		if err = b.copyDir(s[1], filepath.Join(b.out, filepath.FromSlash(s[0]))); err != nil {
+			return
+		}
+	}
+	sort.Strings(b.report.Failed)
+	sort.Strings(b.report.Skipped)
+	return b.report, nil
+}
+
+type builder struct {
+	handler  http.Handler
+	out      string
+	host     string
+	statics  [][2]string // URL前缀与目录
+	queue    []string
+	visited  map[string]bool // 已入队的静态地址
+	articles map[string]bool // 允许生成的文章地址，已解码
+	report   *Report
+}
+
+// target 将站内链接转换为请求地址与静态地址，非站内或无法静态化的链接返回空
+func (b *builder) target(link string) (request, static string) {
+	link = html.UnescapeString(strings.TrimSpace(link))
+	if i := strings.Index(link, "#"); i >= 0 {
+		link = link[:i]
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Opaque != "" {
+		return
+	}
+	if u.Host != "" || u.Scheme != "" {
+		if b.host == "" || u.Host != b.host || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+	}
+	if !strings.HasPrefix(u.Path, "/") {
+		return
+	}
+	for _, p := range excludes {
+		if u.Path == p || strings.HasPrefix(u.Path, p+"/") {
+			return
+		}
+	}
+	for _, s := range b.statics {
+		if strings.HasPrefix(u.Path, s[0]+"/") {
+			return
+		}
+	}
+	for _, p := range articlePrefixes {
+		if strings.HasPrefix(u.Path, p) && !b.articles[unescapePath(u.Path)] {
+			return
+		}
+	}
+	query := u.Query()
+	page := int64(1)
+	for k := range query {
+		switch k {
+		case "page":
+			if page, err = strconv.ParseInt(query.Get(k), 10, 64); err != nil {
+				return
+			}
+		case "pagesize":
+		default: // 搜索等带其他参数的动态地址
+			return
+		}
+	}
+	request = u.Path
+	if len(query) > 0 {
+		request += "?" + query.Encode()
+	}
+	return request, PagePath(u.Path, page)
+}
+
+// PagePath 分页列表的静态地址，第一页保持原地址，如 /life.html 第2页为 /life/page/2/
+func PagePath(p string, page int64) string {
+	if page <= 1 {
+		return p
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(p, "/"), ".html")
+	return fmt.Sprintf("%s/page/%d/", base, page)
+}
+
+func unescapePath(p string) string {
+	if s, err := url.PathUnescape(p); err == nil {
+		return s
+	}
+	return p
+}
+
+// FileName 静态地址对应的输出文件，无扩展名的地址保存为目录下的 index.html
+func FileName(p string) string {
+	p = path.Clean("/" + unescapePath(p))
+	if p == "/" {
+		return "index.html"
+	}
+	if path.Ext(p) == "" || strings.HasSuffix(p, "/") {
+		p += "/index.html"
+	}
+	return strings.TrimPrefix(p, "/")
+}
+
+func (b *builder) enqueue(link string) string {
+	request, static := b.target(link)
+	if request == "" {
+		return ""
+	}
+	if !b.visited[static] {
+		b.visited[static] = true
+		b.queue = append(b.queue, request)
+	}
+	return static
+}
+
+// page 渲染单个地址，HTML 中的站内链接入队并改写分页地址
+func (b *builder) page(request string) (err error) {
+	_, static := b.target(request)
+	req := httptest.NewRequest(http.MethodGet, request, nil)
+	req.Header.Set("User-Agent", userAgent)
+	rec := httptest.NewRecorder()
+	b.handler.ServeHTTP(rec, req)
+	res := rec.Result()
+	body, _ := ioutil.ReadAll(res.Body)
+	switch {
+	case res.StatusCode >= 300 && res.StatusCode < 400:
+		location := res.Header.Get("Location")
+		to := b.enqueue(location)
+		if to == "" {
+			to = location
+		}
+		b.report.Redirects++
+		return b.write(static, redirectPage(to))
+	case res.StatusCode != http.StatusOK:
+		b.report.Failed = append(b.report.Failed, fmt.Sprintf("%s (%d)", request, res.StatusCode))
+		return
+	case strings.Contains(strings.ToLower(res.Header.Get("X-Robots-Tag")), "noindex"):
+		b.report.Skipped = append(b.report.Skipped, request)
+		return
+	}
+	contentType := res.Header.Get("Content-Type")
+	switch {
+	case strings.Contains(contentType, "html"):
+		body = attrLink.ReplaceAllFunc(body, func(m []byte) []byte {
+			sub := attrLink.FindSubmatch(m)
+			to := b.enqueue(string(sub[2]))
+			if to == "" || !strings.Contains(string(sub[2]), "?") {
+				return m
+			}
+			return []byte(fmt.Sprintf(`%s="%s"`, sub[1], html.EscapeString(to)))
+		})
+	case strings.Contains(contentType, "xml"):
+		for _, m := range xmlLink.FindAllSubmatch(body, -1) {
+			b.enqueue(string(m[1]))
+		}
+	}
+	b.report.Pages++
+	return b.write(static, body)
+}
+
+func (b *builder) write(static string, body []byte) (err error) {
+	file := filepath.Join(b.out, filepath.FromSlash(FileName(static)))
+	if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return
+	}
+	return ioutil.WriteFile(file, body, 0644)
+}
+
+// 静态托管无法返回跳转状态码，以页面刷新代替
+func redirectPage(to string) []byte {
+	to = html.EscapeString(to)
+	return []byte(fmt.Sprintf(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="robots" content="noindex">
+<link rel="canonical" href="%[1]s"><meta http-equiv="refresh" content="0; url=%[1]s"></head>
+<body><a href="%[1]s">%[1]s</a></body></html>
+`, to))
+}
+
+func (b *builder) copyDir(src, dst string) error {
+	return filepath.Walk(src, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if fi.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		b.report.Assets++
+		return copyFile(file, target)
+	})
+}
+
+func copyFile(src, dst string) (err error) {
+	r, err := os.Open(src)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+	w, err := os.Create(dst)
+	if err != nil {
+		return
+	}
+	if _, err = io.Copy(w, r); err != nil {
+		w.Close()
+		return
+	}
+	return w.Close()
+}
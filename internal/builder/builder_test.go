@@ -0,0 +1,107 @@
+package builder
+
+import (
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	Convey("PagePath", t, func() {
+		So(PagePath("/life.html", 1), ShouldEqual, "/life.html")
+		So(PagePath("/life.html", 2), ShouldEqual, "/life/page/2/")
+		So(PagePath("/", 3), ShouldEqual, "/page/3/")
+		So(PagePath("/article/5", 2), ShouldEqual, "/article/5/page/2/")
+	})
+	Convey("FileName", t, func() {
+		So(FileName("/"), ShouldEqual, "index.html")
+		So(FileName("/life.html"), ShouldEqual, "life.html")
+		So(FileName("/life/page/2/"), ShouldEqual, "life/page/2/index.html")
+		So(FileName("/article/5"), ShouldEqual, "article/5/index.html")
+		So(FileName("/tag/%E6%8A%80%E6%9C%AF"), ShouldEqual, "tag/技术/index.html")
+		So(FileName("/s/../../etc"), ShouldEqual, "etc/index.html")
+	})
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "builder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	static := filepath.Join(dir, "static")
+	if err = os.MkdirAll(filepath.Join(static, "js"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(static, "js", "a.js"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<a href="/life.html">life</a><script src="/static/js/a.js"></script>`)
+		case "/life.html":
+			page := r.URL.Query().Get("page")
+			fmt.Fprintf(w, `page %s <a href="/life.html?page=2&amp;pagesize=10">2</a>`, page)
+			fmt.Fprint(w, `<a href="/article/1#comments">a</a><a href="/admin">admin</a><a href="/search?q=go">q</a>`)
+		case "/article/1":
+			fmt.Fprint(w, `<a href="/tag/Go">Go</a><a href="//example.com/">x</a><a href="/article/3">draft</a>`)
+			fmt.Fprint(w, `<a href="/s/`+url.PathEscape("加锁")+`">locked</a>`)
+		case "/tag/Go":
+			http.Redirect(w, r, "/tag/go", http.StatusMovedPermanently)
+		case "/tag/go":
+			fmt.Fprint(w, "go")
+		case "/sitemap.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprint(w, `<urlset><url><loc>https://blog.example.com/article/2</loc></url></urlset>`)
+		case "/article/2":
+			fmt.Fprint(w, "two")
+		case "/article/3":
+			fmt.Fprint(w, "draft")
+		case "/s/加锁":
+			w.Header().Set("X-Robots-Tag", "noindex")
+			fmt.Fprint(w, "locked")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	out := filepath.Join(dir, "out")
+
+	Convey("Run", t, func() {
+		report, err := Run(mux, &Options{Output: out, StaticDir: "static:" + static, SiteURL: "https://blog.example.com",
+			Articles: []string{"/article/1", "/article/2", "/s/" + url.PathEscape("加锁")}})
+		So(err, ShouldBeNil)
+		read := func(name string) string {
+			b, err := ioutil.ReadFile(filepath.Join(out, filepath.FromSlash(name)))
+			if err != nil {
+				return ""
+			}
+			return string(b)
+		}
+		So(read("index.html"), ShouldContainSubstring, `href="/life.html"`)
+		So(read("life.html"), ShouldContainSubstring, `href="/life/page/2/"`)
+		So(read("life.html"), ShouldContainSubstring, `href="/search?q=go"`)
+		So(read("life/page/2/index.html"), ShouldStartWith, "page 2")
+		So(read("article/1/index.html"), ShouldNotBeEmpty)
+		So(read("article/2/index.html"), ShouldEqual, "two")
+		So(read("tag/Go/index.html"), ShouldContainSubstring, `url=/tag/go`)
+		So(read("tag/go/index.html"), ShouldEqual, "go")
+		So(read("static/js/a.js"), ShouldEqual, "a")
+		_, err = os.Stat(filepath.Join(out, "admin"))
+		So(os.IsNotExist(err), ShouldBeTrue)
+		So(read("article/3/index.html"), ShouldBeEmpty)
+		So(read("s/加锁/index.html"), ShouldBeEmpty)
+		So(report.Skipped, ShouldResemble, []string{"/s/加锁"})
+		So(report.Redirects, ShouldEqual, 1)
+		So(report.Assets, ShouldEqual, 1)
+		So(strings.Join(report.Failed, ","), ShouldContainSubstring, "/feed.xml (404)")
+	})
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/griffin702/ginana/library/conf/paladin"
+	xtime "github.com/griffin702/ginana/library/time"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Files 由 paladin 载入并合并到 Config 的配置文件
+var Files = []string{"global.toml", "db.toml", "http.toml", "casbin.toml", "memcache.toml"}
+
+// Check 逐个解析配置文件并校验，报告未知的配置项与环境变量、无效的时间间隔，
+// 应用覆盖后再检查必填项，需在 paladin.Init 之后调用
+func Check() (problems []string) {
+	cfg := new(Config)
+	for _, key := range Files {
+		text, err := paladin.Get(key).Raw()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		found, invalid := CheckKeys(text)
+		for _, p := range found {
+			problems = append(problems, key+": "+p)
+		}
+		// 无效的取值已逐项报告，不再重复解码错误
+		if err = toml.Unmarshal([]byte(text), cfg); err != nil && !invalid {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+	for _, name := range UnknownEnv() {
+		problems = append(problems, fmt.Sprintf("%s: 未知的环境变量", name))
+	}
+	if err := cfg.Override(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return append(problems, cfg.Validate()...)
+}
+
+var durationType = reflect.TypeOf(xtime.Duration(0))
+
+// CheckKeys 检查 TOML 文本中不对应任何配置项的键与无效的时间间隔，
+// invalid 表示存在无法解码的取值
+func CheckKeys(text string) (problems []string, invalid bool) {
+	var m map[string]interface{}
+	if _, err := toml.Decode(text, &m); err != nil {
+		return []string{err.Error()}, true
+	}
+	var walk func(prefix string, m map[string]interface{}, t reflect.Type)
+	walk = func(prefix string, m map[string]interface{}, t reflect.Type) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			f, ok := fieldByKey(t, k)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("未知的配置项 %s%s", prefix, k))
+				continue
+			}
+			switch v := m[k].(type) {
+			case map[string]interface{}:
+				if f.Type.Kind() == reflect.Ptr && f.Type.Elem().Kind() == reflect.Struct {
+					walk(prefix+k+".", v, f.Type.Elem())
+				}
+			case string:
+				if f.Type == durationType {
+					if _, err := time.ParseDuration(v); err != nil {
+						problems = append(problems, fmt.Sprintf("%s%s: 无效的时间间隔 %q", prefix, k, v))
+						invalid = true
+					}
+				}
+			default:
+				if f.Type == durationType {
+					problems = append(problems, fmt.Sprintf("%s%s: 时间间隔应写作带单位的字符串，如 \"5s\"", prefix, k))
+					invalid = true
+				}
+			}
+		}
+	}
+	walk("", m, reflect.TypeOf(Config{}))
+	return
+}
+
+// fieldByKey 与 TOML 解码规则一致，键名不区分大小写
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	if f, ok := t.FieldByName(key); ok {
+		return f, true
+	}
+	return t.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, key) })
+}
+
+// Validate 检查必填项与取值范围
+func (c *Config) Validate() (problems []string) {
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	if c.MySQL == nil {
+		add("缺少 [MySQL] 配置")
+	} else {
+		if c.MySQL.Driver == "" || c.MySQL.DbName == "" || c.MySQL.DbHost == "" {
+			add("MySQL: driver、dbName、dbHost 不能为空")
+		}
+	}
+	if c.Server == nil {
+		add("Server: addr 不能为空")
+	} else {
+		problems = append(problems, c.Server.Validate()...)
+	}
+	if c.Memcache == nil || c.Memcache.Addr == "" {
+		add("Memcache: addr 不能为空")
+	}
+	if c.Casbin != nil && c.Casbin.Enable && c.Casbin.Model == "" {
+		add("Casbin: 启用时 model 不能为空")
+	}
+	if c.JwtSecret == "" {
+		add("jwtSecret 不能为空")
+	}
+	if c.EnableTemplate {
+		if _, err := os.Stat(c.ViewsPath); err != nil {
+			add("viewsPath: %v", err)
+		}
+		if _, dir := StaticRoot(c.StaticDir); dir != "" {
+			if _, err := os.Stat(dir); err != nil {
+				add("staticDir: %v", err)
+			}
+		}
+	}
+	if c.BackupInterval < 0 || c.ViewFlush < 0 || c.ViewWindow < 0 {
+		add("viewFlush、viewWindow、backupInterval 不能为负数")
+	}
+	return
+}
+
+// Validate 检查 [Server] 配置，重新载入 http.toml 时单独使用
+func (s *ServerConfig) Validate() (problems []string) {
+	if s.Addr == "" {
+		problems = append(problems, "Server: addr 不能为空")
+	}
+	if s.ReadTimeout < 0 || s.WriteTimeout < 0 || s.IdleTimeout < 0 || s.ShutdownTimeout < 0 {
+		problems = append(problems, "Server: 超时时间不能为负数")
+	}
+	return
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/griffin702/ginana/library/database"
 	"github.com/griffin702/ginana/library/log"
 	xtime "github.com/griffin702/ginana/library/time"
+	"path/filepath"
+	"strings"
 )
 
 type Config struct {
@@ -27,6 +29,13 @@ type Config struct {
 	StaticDir              string
 	SessionAndCookieExpire xtime.Duration
 	JwtSecret              string
+	ViewFlush              xtime.Duration // 浏览计数与访问统计写库间隔
+	ViewWindow             xtime.Duration // 同一访客重复浏览不计数的时间窗口
+	BackupDir              string         // 定时备份保存目录
+	BackupInterval         xtime.Duration // 定时备份间隔，为0时不备份
+	BackupKeep             int            // 保留的定时备份数量
+	MonitorAllow           string         // 允许访问健康检查与指标的来源地址或网段，空格分隔
+	MonitorToken           string         // 访问健康检查与指标的令牌，为空时只按来源地址放行
 }
 
 type ServerConfig struct {
@@ -34,15 +43,28 @@ type ServerConfig struct {
 	ReadTimeout  xtime.Duration
 	WriteTimeout xtime.Duration
 	IdleTimeout  xtime.Duration
+	// 优雅退出时等待进行中请求的最长时间，超时后强制关闭连接
+	ShutdownTimeout xtime.Duration
 }
 
 var (
 	global  *Config
-	defPath = "../configs/global.toml" // 默认配置文件
+	defPath = "../configs" // 默认配置目录
 )
 
+// GetBaseConfig 读取配置目录下的 global.toml 并应用覆盖，配置目录可以通过
+// -configPath 或 GINANA_CONFIGPATH 指定
 func GetBaseConfig() (cfg *Config, err error) {
-	cfg, err = ParseToml(defPath)
+	dir := defPath
+	if value, _, ok := lookup(&Item{Path: "ConfigPath"}); ok {
+		dir = value
+	}
+	if cfg, err = ParseToml(filepath.Join(dir, "global.toml")); err != nil {
+		return
+	}
+	if err = cfg.Override(); err != nil {
+		return
+	}
 	global = cfg
 	return
 }
@@ -57,16 +79,24 @@ func ParseToml(path string) (*Config, error) {
 	return &c, nil
 }
 
+// Load 将配置文件合并到 cfg，随后应用命令行参数与环境变量的覆盖
+func Load(key string, cfg *Config) (err error) {
+	if err = paladin.Get(key).UnmarshalTOML(cfg); err != nil {
+		return
+	}
+	return cfg.Override()
+}
+
 // NewConfig 初始化全局配置并自动载入
 func NewConfig() (cfg *Config, err error) {
 	key := "global.toml"
-	if err = paladin.Get(key).UnmarshalTOML(global); err != nil {
+	if err = Load(key, global); err != nil {
 		return
 	}
 	cfg = global
 	go func() {
 		for range paladin.WatchEvent(context.Background(), key) {
-			if err := paladin.Get(key).UnmarshalTOML(global); err != nil {
+			if err := Load(key, global); err != nil {
 				log.Errorf("config load error: %v", err)
 				continue
 			}
@@ -82,3 +112,15 @@ func Global() *Config {
 	}
 	return global
 }
+
+// StaticRoot 解析 StaticDir 配置中的第一项，格式为 URL前缀:目录，如 static:../static
+func StaticRoot(staticDir string) (prefix, dir string) {
+	first := strings.Fields(staticDir)
+	if len(first) == 0 {
+		return "/static", "../static"
+	}
+	if i := strings.Index(first[0], ":"); i > 0 {
+		return "/" + strings.Trim(first[0][:i], "/"), first[0][i+1:]
+	}
+	return "/static", first[0]
+}
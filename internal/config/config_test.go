@@ -0,0 +1,88 @@
+package config
+
+import (
+	"flag"
+	xtime "github.com/griffin702/ginana/library/time"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	RegisterFlags(fs)
+	return fs
+}
+
+func TestOverride(t *testing.T) {
+	Convey("Override", t, func() {
+		defer func() { flagValues = make(map[string]string) }()
+		os.Setenv("GINANA_MYSQL_DBPWD", "env")
+		os.Setenv("GINANA_SERVER_ADDR", "env:1")
+		os.Setenv("GINANA_VIEWFLUSH", "2m")
+		defer os.Unsetenv("GINANA_MYSQL_DBPWD")
+		defer os.Unsetenv("GINANA_SERVER_ADDR")
+		defer os.Unsetenv("GINANA_VIEWFLUSH")
+		fs := newFlagSet()
+		So(fs.Parse([]string{"-server.addr=:8000", "-enableGzip", "-memcache.idleConns", "3"}), ShouldBeNil)
+
+		cfg := &Config{JwtSecret: "file", Server: &ServerConfig{Addr: "file:1"}}
+		So(cfg.Override(), ShouldBeNil)
+		So(cfg.JwtSecret, ShouldEqual, "file")
+		So(cfg.MySQL.DbPwd, ShouldEqual, "env")
+		So(cfg.Server.Addr, ShouldEqual, ":8000")
+		So(cfg.EnableGzip, ShouldBeTrue)
+		So(cfg.Memcache.IdleConns, ShouldEqual, 3)
+		So(cfg.ViewFlush, ShouldEqual, xtime.Duration(2*time.Minute))
+
+		os.Setenv("GINANA_VIEWFLUSH", "2x")
+		So(cfg.Override(), ShouldNotBeNil)
+		So(fs.Parse([]string{"-server.readTimeout=1"}), ShouldNotBeNil)
+	})
+}
+
+func TestCheckKeys(t *testing.T) {
+	Convey("CheckKeys", t, func() {
+		problems, invalid := CheckKeys(`
+jwtSecret = "x"
+unknown = 1
+[MySQL]
+    dbPwd = "x"
+    idleTimeout = "4h"
+[Memcache]
+    idle = 10
+    timeout = "300"
+[Server]
+    readTimeout = 5
+`)
+		So(invalid, ShouldBeTrue)
+		So(problems, ShouldResemble, []string{
+			`未知的配置项 Memcache.idle`,
+			`Memcache.timeout: 无效的时间间隔 "300"`,
+			`Server.readTimeout: 时间间隔应写作带单位的字符串，如 "5s"`,
+			`未知的配置项 unknown`,
+		})
+		problems, invalid = CheckKeys("[Server]\n    addr = \":8000\"\n")
+		So(invalid, ShouldBeFalse)
+		So(problems, ShouldBeEmpty)
+	})
+}
+
+func TestStaticRoot(t *testing.T) {
+	Convey("解析静态目录配置", t, func() {
+		prefix, d := StaticRoot("static:../static")
+		So(prefix, ShouldEqual, "/static")
+		So(d, ShouldEqual, "../static")
+
+		prefix, d = StaticRoot("/assets/:/data/assets other:../other")
+		So(prefix, ShouldEqual, "/assets")
+		So(d, ShouldEqual, "/data/assets")
+
+		prefix, d = StaticRoot("")
+		So(prefix, ShouldEqual, "/static")
+		So(d, ShouldEqual, "../static")
+	})
+}
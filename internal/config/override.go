@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 配置项的取值优先级从高到低为：命令行参数、环境变量、配置文件。
+// 每个配置项都可以覆盖，环境变量名为 GINANA_ 加大写的配置路径，如 GINANA_MYSQL_DBPWD，
+// 命令行参数名与配置文件中的写法一致，如 -mysql.dbPwd、-jwtSecret
+const EnvPrefix = "GINANA_"
+
+// 可覆盖的分组，对应配置文件中的表
+var sections = []string{"MySQL", "Casbin", "Memcache", "Server"}
+
+// flagValues 命令行中出现的配置项，键为配置路径
+var flagValues = make(map[string]string)
+
+// Item 可覆盖的配置项
+type Item struct {
+	Path string // 配置路径，如 MySQL.DbPwd
+	Type reflect.Type
+}
+
+// Env 环境变量名
+func (i *Item) Env() string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(i.Path, ".", "_", -1))
+}
+
+// Flag 命令行参数名
+func (i *Item) Flag() string {
+	parts := strings.Split(i.Path, ".")
+	if len(parts) == 2 {
+		return strings.ToLower(parts[0]) + "." + lowerFirst(parts[1])
+	}
+	return lowerFirst(parts[0])
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+// Items 全部可覆盖的配置项，按配置路径排序
+func Items() (items []*Item) {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isSection(f.Name) {
+			st := f.Type.Elem()
+			for j := 0; j < st.NumField(); j++ {
+				items = append(items, &Item{Path: f.Name + "." + st.Field(j).Name, Type: st.Field(j).Type})
+			}
+			continue
+		}
+		items = append(items, &Item{Path: f.Name, Type: f.Type})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Path < items[j].Path })
+	return
+}
+
+func isSection(name string) bool {
+	for _, s := range sections {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+type flagValue struct {
+	item *Item
+}
+
+func (v *flagValue) String() string { return "" }
+
+func (v *flagValue) Set(s string) error {
+	if err := setValue(reflect.New(v.item.Type).Elem(), s); err != nil {
+		return err
+	}
+	flagValues[v.item.Path] = s
+	return nil
+}
+
+// IsBoolFlag 允许布尔配置项写作 -enableGzip
+func (v *flagValue) IsBoolFlag() bool {
+	return v.item.Type.Kind() == reflect.Bool
+}
+
+// RegisterFlags 为每个配置项注册命令行参数，解析后的取值在 Override 时生效
+func RegisterFlags(fs *flag.FlagSet) {
+	for _, item := range Items() {
+		fs.Var(&flagValue{item: item}, item.Flag(), "覆盖 "+item.Path+"，环境变量 "+item.Env())
+	}
+}
+
+// lookup 按优先级取得配置项的覆盖值
+func lookup(item *Item) (value, source string, ok bool) {
+	if value, ok = flagValues[item.Path]; ok {
+		return value, "-" + item.Flag(), true
+	}
+	if value, ok = os.LookupEnv(item.Env()); ok {
+		return value, item.Env(), true
+	}
+	return
+}
+
+// Override 以命令行参数与环境变量覆盖配置文件中的取值，每次载入配置文件后调用
+func (c *Config) Override() (err error) {
+	v := reflect.ValueOf(c).Elem()
+	for _, item := range Items() {
+		value, source, ok := lookup(item)
+		if !ok {
+			continue
+		}
+		field := v
+		for _, name := range strings.Split(item.Path, ".") {
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					field.Set(reflect.New(field.Type().Elem()))
+				}
+				field = field.Elem()
+			}
+			field = field.FieldByName(name)
+		}
+		if err = setValue(field, value); err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+	}
+	return
+}
+
+// UnknownEnv 以 GINANA_ 开头但不对应任何配置项的环境变量
+func UnknownEnv() (list []string) {
+	known := make(map[string]bool)
+	for _, item := range Items() {
+		known[item.Env()] = true
+	}
+	for _, kv := range os.Environ() {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if strings.HasPrefix(name, EnvPrefix) && !known[name] {
+			list = append(list, name)
+		}
+	}
+	sort.Strings(list)
+	return
+}
+
+func setValue(v reflect.Value, s string) (err error) {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Int, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(s, 10, 64); err == nil {
+			v.SetInt(i)
+		}
+	default:
+		err = fmt.Errorf("不支持的类型 %s", v.Type())
+	}
+	return
+}
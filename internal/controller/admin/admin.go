@@ -43,6 +43,14 @@ func (c *CAdmin) Get() (err error) {
 	adminData.CountUsers = c.Svc.CountUsers()
 	adminData.CountTags = c.Svc.CountTags()
 	c.Ctx.ViewData("data", adminData)
+	stat, err := c.Svc.GetStatReport(model.StatQueryParam{
+		Start: c.Ctx.URLParam("start"),
+		End:   c.Ctx.URLParam("end"),
+	})
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("stat", stat)
 	c.setHeadMetas()
 	c.Ctx.View("admin/index.html")
 	return
@@ -3,6 +3,7 @@ package admin
 import (
 	"fmt"
 	"ginana-blog/internal/model"
+	"strings"
 )
 
 func (c *CAdmin) GetArticleList() (err error) {
@@ -40,6 +41,9 @@ func (c *CAdmin) PostArticleList() (err error) {
 }
 
 func (c *CAdmin) GetArticleAdd() (err error) {
+	if err = c.setArticleOptions(); err != nil {
+		return
+	}
 	c.setHeadMetas("文章创建")
 	c.Ctx.View("admin/article/add.html")
 	return
@@ -67,6 +71,9 @@ func (c *CAdmin) GetArticleEditBy(id int64) (err error) {
 	if err != nil {
 		return
 	}
+	if err = c.setArticleOptions(); err != nil {
+		return
+	}
 	c.Ctx.ViewData("data", article)
 	c.setHeadMetas("文章编辑")
 	c.Ctx.View("admin/article/edit.html")
@@ -110,3 +117,35 @@ func (c *CAdmin) GetArticlePushBy(id int64) (err error) {
 	c.ShowMsg(fmt.Sprintf("提交链接%s到百度成功,返回:%s", url, resp))
 	return
 }
+
+// 文章编辑页的分类下拉列表与可见角色列表
+func (c *CAdmin) setArticleOptions() (err error) {
+	tree, err := c.Svc.GetCategoryTree()
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("categories", tree.Flatten())
+	roles, err := c.Svc.GetAllRoles()
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("roles", roles.List)
+	return
+}
+
+// GetArticleSlug 编辑器中预览文章URL名称
+func (c *CAdmin) GetArticleSlug() {
+	urltype := int8(c.Ctx.URLParamIntDefault("urltype", 0))
+	slug, err := c.Svc.ArticleSlug(c.Ctx.URLParam("title"), c.Ctx.URLParam("urlname"),
+		urltype, c.Ctx.URLParamInt64Default("id", 0))
+	if err != nil {
+		c.Ctx.JSON(c.JsonPlus(nil, err))
+		return
+	}
+	article := &model.Article{ID: c.Ctx.URLParamInt64Default("id", 0), Urlname: slug, Urltype: urltype}
+	c.Ctx.JSON(c.JsonPlus(map[string]string{
+		"slug": slug,
+		"url":  strings.TrimRight(c.SiteOptions.SiteURL, "/") + article.Link(),
+	}, nil))
+	return
+}
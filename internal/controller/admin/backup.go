@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"ginana-blog/internal/backup"
+	"path/filepath"
+	"time"
+)
+
+// GetSystemBackup 列出备份目录中的备份
+func (c *CAdmin) GetSystemBackup() (err error) {
+	list, err := backup.List(c.Config.BackupDir)
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("data", list)
+	c.Ctx.ViewData("dir", c.Config.BackupDir)
+	c.Ctx.ViewData("keep", c.Config.BackupKeep)
+	if interval := time.Duration(c.Config.BackupInterval); interval > 0 {
+		c.Ctx.ViewData("interval", interval.String())
+	}
+	c.setHeadMetas("数据备份")
+	c.Ctx.View("admin/system/backup.html")
+	return
+}
+
+// PostSystemBackup 立即备份到备份目录
+func (c *CAdmin) PostSystemBackup() (err error) {
+	file, err := backup.Save(c.Svc, c.Config.BackupDir, c.Config.StaticDir, c.Config.BackupKeep)
+	if err != nil {
+		c.ShowMsg("备份失败：" + err.Error())
+		return nil
+	}
+	c.ShowMsg("已备份到 "+filepath.Base(file), "/admin/system/backup")
+	return
+}
+
+// GetSystemBackupBy 下载备份，只允许下载备份目录中列出的文件
+func (c *CAdmin) GetSystemBackupBy(name string) (err error) {
+	list, err := backup.List(c.Config.BackupDir)
+	if err != nil {
+		return
+	}
+	for _, f := range list {
+		if f.Name == name {
+			return c.Ctx.SendFile(filepath.Join(c.Config.BackupDir, f.Name), f.Name)
+		}
+	}
+	return c.Hm.GetMessage(404, "404 not found")
+}
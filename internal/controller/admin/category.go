@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"ginana-blog/internal/model"
+)
+
+func (c *CAdmin) GetCategoryList() (err error) {
+	tree, err := c.Svc.GetCategoryTree()
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("data", tree)
+	c.setHeadMetas("分类管理")
+	c.Ctx.View("admin/category/list.html")
+	return
+}
+
+func (c *CAdmin) PostCategorySort() {
+	var nodes []*model.CategorySortReq
+	if err := c.Ctx.ReadJSON(&nodes); err != nil {
+		c.Ctx.JSON(c.JsonPlus(false, err))
+		return
+	}
+	if err := c.Svc.SortCategories(nodes); err != nil {
+		c.Ctx.JSON(c.JsonPlus(false, err))
+		return
+	}
+	c.Ctx.JSON(c.JsonPlus(true, "分类排序已保存"))
+	return
+}
+
+func (c *CAdmin) GetCategoryAdd() (err error) {
+	tree, err := c.Svc.GetCategoryTree()
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("categories", tree.Flatten())
+	c.Ctx.ViewData("data", &model.Category{ParentID: c.Ctx.URLParamInt64Default("parent_id", 0)})
+	c.setHeadMetas("分类创建")
+	c.Ctx.View("admin/category/add.html")
+	return
+}
+
+func (c *CAdmin) PostCategoryAdd() (err error) {
+	req := new(model.CreateCategoryReq)
+	if err = c.Ctx.ReadForm(req); err != nil {
+		return
+	}
+	if err = c.Valid(req); err != nil {
+		return
+	}
+	if _, err = c.Svc.CreateCategory(req); err != nil {
+		return
+	}
+	c.setHeadMetas("分类创建")
+	c.ShowMsg("分类已创建", "/admin/category/list")
+	return
+}
+
+func (c *CAdmin) GetCategoryEditBy(id int64) (err error) {
+	category, err := c.Svc.GetCategory(id)
+	if err != nil {
+		return
+	}
+	tree, err := c.Svc.GetCategoryTree()
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("categories", tree.Flatten())
+	c.Ctx.ViewData("data", category)
+	c.setHeadMetas("分类编辑")
+	c.Ctx.View("admin/category/edit.html")
+	return
+}
+
+func (c *CAdmin) PostCategoryEditBy(id int64) (err error) {
+	req := new(model.UpdateCategoryReq)
+	if err = c.Ctx.ReadForm(req); err != nil {
+		return
+	}
+	req.ID = id
+	if err = c.Valid(req); err != nil {
+		return
+	}
+	if _, err = c.Svc.UpdateCategory(req); err != nil {
+		return
+	}
+	c.setHeadMetas("分类更新")
+	c.ShowMsg("分类已更新", "/admin/category/list")
+	return
+}
+
+func (c *CAdmin) GetCategoryDeleteBy(id int64) (err error) {
+	if err = c.Svc.DeleteCategory(id); err != nil {
+		return
+	}
+	c.setHeadMetas("删除分类")
+	c.ShowMsg("分类已删除", "/admin/category/list")
+	return
+}
@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"fmt"
+	"ginana-blog/internal/exporter"
+	"github.com/griffin702/ginana/library/log"
+	"time"
+)
+
+// GetSystemExport 下载全部内容的 Markdown 压缩包
+func (c *CAdmin) GetSystemExport() (err error) {
+	data, err := c.Svc.GetExportData()
+	if err != nil {
+		return
+	}
+	name := fmt.Sprintf("ginana-export-%s.zip", time.Now().Format("20060102-150405"))
+	c.Ctx.ContentType("application/zip")
+	c.Ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	if err = exporter.Write(c.Ctx.ResponseWriter(), data, c.Config.StaticDir); err != nil {
+		// 响应已开始写入，只能记录日志
+		log.Errorf("export error(%v)", err)
+	}
+	return nil
+}
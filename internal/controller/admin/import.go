@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"ginana-blog/internal/importer"
+	"ginana-blog/internal/model"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func (c *CAdmin) GetSystemImport() (err error) {
+	c.Ctx.ViewData("formats", model.ImportFormats)
+	c.setHeadMetas("内容导入")
+	c.Ctx.View("admin/system/import.html")
+	return
+}
+
+// PostSystemImport 上传导出文件，Markdown 目录及带图片的导出需打包为 zip
+func (c *CAdmin) PostSystemImport() (err error) {
+	format := c.Ctx.FormValue("format")
+	if !model.IsImportFormat(format) {
+		c.ShowMsg("请选择导入格式")
+		return
+	}
+	f, h, err := c.Ctx.FormFile("file")
+	if err != nil {
+		c.ShowMsg("请选择要导入的文件")
+		return nil
+	}
+	defer f.Close()
+	tmp, err := ioutil.TempDir("", "ginana-import")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(tmp)
+	file := filepath.Join(tmp, filepath.Base(h.Filename))
+	w, err := os.Create(file)
+	if err != nil {
+		return
+	}
+	_, err = io.Copy(w, f)
+	w.Close()
+	if err != nil {
+		return
+	}
+	path := file
+	if strings.EqualFold(filepath.Ext(file), ".zip") {
+		path = filepath.Join(tmp, "src")
+		if err = importer.Unzip(file, path); err != nil {
+			c.ShowMsg(err.Error())
+			return nil
+		}
+	} else if format == model.ImportHexo || format == model.ImportHugo {
+		c.ShowMsg("Markdown 目录请打包为 zip 后上传")
+		return
+	}
+	report, err := importer.Run(c.Svc, &importer.Options{
+		Format:    format,
+		Path:      path,
+		StaticDir: c.Config.StaticDir,
+		SiteURL:   strings.TrimSpace(c.Ctx.FormValue("site_url")),
+		DryRun:    c.Ctx.FormValue("dry_run") != "",
+	})
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("data", report)
+	c.setHeadMetas("导入结果")
+	c.Ctx.View("admin/system/importReport.html")
+	return
+}
@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"ginana-blog/internal/model"
+)
+
+func (c *CAdmin) GetRedirectList() (err error) {
+	redirects, err := c.Svc.GetRedirects(c.Pager)
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("data", redirects)
+	c.setHeadMetas("跳转管理")
+	c.Ctx.View("admin/redirect/list.html")
+	return
+}
+
+func (c *CAdmin) GetRedirectAdd() (err error) {
+	c.Ctx.ViewData("data", &model.Redirect{Code: 301})
+	c.setHeadMetas("跳转创建")
+	c.Ctx.View("admin/redirect/add.html")
+	return
+}
+
+func (c *CAdmin) PostRedirectAdd() (err error) {
+	req := new(model.CreateRedirectReq)
+	if err = c.Ctx.ReadForm(req); err != nil {
+		return
+	}
+	if err = c.Valid(req); err != nil {
+		return
+	}
+	if _, err = c.Svc.CreateRedirect(req); err != nil {
+		return
+	}
+	c.setHeadMetas("跳转创建")
+	c.ShowMsg("跳转已创建", "/admin/redirect/list")
+	return
+}
+
+func (c *CAdmin) GetRedirectEditBy(id int64) (err error) {
+	redirect, err := c.Svc.GetRedirect(id)
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("data", redirect)
+	c.setHeadMetas("跳转编辑")
+	c.Ctx.View("admin/redirect/edit.html")
+	return
+}
+
+func (c *CAdmin) PostRedirectEditBy(id int64) (err error) {
+	req := new(model.UpdateRedirectReq)
+	if err = c.Ctx.ReadForm(req); err != nil {
+		return
+	}
+	req.ID = id
+	if err = c.Valid(req); err != nil {
+		return
+	}
+	if _, err = c.Svc.UpdateRedirect(req); err != nil {
+		return
+	}
+	c.setHeadMetas("跳转更新")
+	c.ShowMsg("跳转已更新", "/admin/redirect/list")
+	return
+}
+
+func (c *CAdmin) GetRedirectDeleteBy(id int64) (err error) {
+	if err = c.Svc.DeleteRedirect(id); err != nil {
+		return
+	}
+	c.setHeadMetas("删除跳转")
+	c.ShowMsg("跳转已删除", "/admin/redirect/list")
+	return
+}
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"ginana-blog/internal/model"
+)
+
+func (c *CAdmin) GetSeriesList() (err error) {
+	series, err := c.Svc.GetSeriesList(c.Pager)
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("data", series)
+	c.setHeadMetas("系列列表")
+	c.Ctx.View("admin/series/list.html")
+	return
+}
+
+func (c *CAdmin) GetSeriesAdd() (err error) {
+	articles, err := c.Svc.GetArticleTitles()
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("articles", articles)
+	c.Ctx.ViewData("data", new(model.Series))
+	c.setHeadMetas("系列创建")
+	c.Ctx.View("admin/series/add.html")
+	return
+}
+
+func (c *CAdmin) PostSeriesAdd() (err error) {
+	req := new(model.CreateSeriesReq)
+	if err = c.Ctx.ReadForm(req); err != nil {
+		return
+	}
+	if err = c.Valid(req); err != nil {
+		return
+	}
+	if _, err = c.Svc.CreateSeries(req); err != nil {
+		return
+	}
+	c.setHeadMetas("系列创建")
+	c.ShowMsg("系列已创建", "/admin/series/list")
+	return
+}
+
+func (c *CAdmin) GetSeriesEditBy(id int64) (err error) {
+	series, err := c.Svc.GetSeries(id)
+	if err != nil {
+		return
+	}
+	articles, err := c.Svc.GetArticleTitles()
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("articles", articles)
+	c.Ctx.ViewData("data", series)
+	c.setHeadMetas("系列编辑")
+	c.Ctx.View("admin/series/edit.html")
+	return
+}
+
+func (c *CAdmin) PostSeriesEditBy(id int64) (err error) {
+	req := new(model.UpdateSeriesReq)
+	if err = c.Ctx.ReadForm(req); err != nil {
+		return
+	}
+	req.ID = id
+	if err = c.Valid(req); err != nil {
+		return
+	}
+	if _, err = c.Svc.UpdateSeries(req); err != nil {
+		return
+	}
+	c.setHeadMetas("系列更新")
+	c.ShowMsg("系列已更新", "/admin/series/list")
+	return
+}
+
+func (c *CAdmin) GetSeriesDeleteBy(id int64) (err error) {
+	if err = c.Svc.DeleteSeries(id); err != nil {
+		return
+	}
+	c.setHeadMetas("删除系列")
+	c.ShowMsg("系列已删除")
+	return
+}
@@ -28,3 +28,31 @@ func (c *CAdmin) PostTagList() (err error) {
 	c.ShowMsg("标签批量处理完成")
 	return
 }
+
+func (c *CAdmin) GetTagEditBy(id int64) (err error) {
+	tag, err := c.Svc.GetTag(id)
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("data", tag)
+	c.setHeadMetas("标签编辑")
+	c.Ctx.View("admin/tag/edit.html")
+	return
+}
+
+func (c *CAdmin) PostTagEditBy(id int64) (err error) {
+	req := new(model.UpdateTagReq)
+	if err = c.Ctx.ReadForm(req); err != nil {
+		return
+	}
+	req.ID = id
+	if err = c.Valid(req); err != nil {
+		return
+	}
+	if _, err = c.Svc.UpdateTag(req); err != nil {
+		return
+	}
+	c.setHeadMetas("标签更新")
+	c.ShowMsg("标签已更新", "/admin/tag/list")
+	return
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"ginana-blog/internal/controller"
+)
+
+// CApi 前台JSON接口
+type CApi struct {
+	controller.BaseController
+}
+
+func (c *CApi) fail(err error) {
+	c.Ctx.JSON(c.JsonPlus(nil, err))
+}
@@ -0,0 +1,49 @@
+package api
+
+// GetArticleBy godoc
+// @Description 获取文章详情，包含渲染后的正文、目录大纲、字数与阅读时长；无权查看时仅返回摘要，locked 为 true
+// @Tags Article
+// @Accept  json
+// @Produce  json
+// @Param id path int true "文章ID"
+// @Success 200 {object} model.JSON{data=model.Article}
+// @Failure 500 {object} model.JSON
+// @Router /article/{id} [get]
+func (c *CApi) GetArticleBy(id int64) {
+	article, err := c.Svc.GetArticle(id)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	if article.Status != 0 {
+		c.fail(c.Hm.GetMessage(404))
+		return
+	}
+	c.LockArticle(article)
+	c.Ctx.JSON(c.JsonPlus(article, nil))
+	return
+}
+
+// GetArticleByRelated godoc
+// @Description 获取相关文章列表，按标签重合度与正文相似度排序
+// @Tags Article
+// @Accept  json
+// @Produce  json
+// @Param id path int true "文章ID"
+// @Param size query int false "数量，默认取站点设置"
+// @Success 200 {object} model.JSON{data=[]model.Article}
+// @Failure 500 {object} model.JSON
+// @Router /article/{id}/related [get]
+func (c *CApi) GetArticleByRelated(id int64) {
+	size := c.Ctx.URLParamIntDefault("size", c.SiteOptions.GetRelatedSize())
+	if size > 20 {
+		size = 20
+	}
+	articles, err := c.Svc.GetRelatedArticles(id, size)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	c.Ctx.JSON(c.JsonPlus(articles, nil))
+	return
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"ginana-blog/internal/model"
+)
+
+// GetArticleByReactions godoc
+// @Description 获取文章的点赞与表情回应数，reacted表示当前访客是否已回应
+// @Tags Reaction
+// @Accept  json
+// @Produce  json
+// @Param id path int true "文章ID"
+// @Success 200 {object} model.JSON{data=[]model.ReactionCount}
+// @Failure 500 {object} model.JSON
+// @Router /article/{id}/reactions [get]
+func (c *CApi) GetArticleByReactions(id int64) {
+	list, err := c.Svc.GetArticleReactions(id, c.UserID, c.VisitorID(), c.GetClientIP())
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	c.Ctx.JSON(c.JsonPlus(list, nil))
+	return
+}
+
+// PostArticleByReaction godoc
+// @Description 点赞或表情回应，重复提交同一表情则取消；登录用户按用户去重，游客按Cookie与IP去重
+// @Tags Reaction
+// @Accept  json
+// @Produce  json
+// @Param id path int true "文章ID"
+// @Param name formData string true "表情名称，如like"
+// @Success 200 {object} model.JSON{data=[]model.ReactionCount}
+// @Failure 500 {object} model.JSON
+// @Router /article/{id}/reaction [post]
+func (c *CApi) PostArticleByReaction(id int64) {
+	req := new(model.ReactionReq)
+	if err := c.Ctx.ReadForm(req); err != nil {
+		c.fail(err)
+		return
+	}
+	req.ArticleID = id
+	req.UserID = c.UserID
+	req.VisitorID = c.VisitorID()
+	req.IP = c.GetClientIP()
+	if err := c.Valid(req); err != nil {
+		c.fail(err)
+		return
+	}
+	list, err := c.Svc.ToggleReaction(req)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	c.Ctx.JSON(c.JsonPlus(list, nil))
+	return
+}
@@ -0,0 +1,19 @@
+package api
+
+// GetTagResolve godoc
+// @Description 将逗号分隔的标签名解析为规范名称，同义词替换为对应标签
+// @Tags Tag
+// @Accept  json
+// @Produce  json
+// @Param names query string true "标签名，逗号分隔"
+// @Success 200 {object} model.JSON{data=[]string}
+// @Failure 500 {object} model.JSON
+// @Router /tag/resolve [get]
+func (c *CApi) GetTagResolve() {
+	names := c.Svc.ResolveTagNames(c.Ctx.URLParam("names"))
+	if names == nil {
+		names = []string{}
+	}
+	c.Ctx.JSON(c.JsonPlus(names, nil))
+	return
+}
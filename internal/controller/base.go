@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"ginana-blog/internal/config"
 	"ginana-blog/internal/model"
 	"ginana-blog/internal/service"
@@ -8,6 +10,7 @@ import (
 	"github.com/griffin702/service/tools"
 	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/sessions"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -32,6 +35,9 @@ type BaseController struct {
 func (c *BaseController) BeginRequest(ctx iris.Context) {
 	user := c.ParseToken()
 	c.UserID = user.ID
+	if user.ID > 0 {
+		ctx.Values().Set(model.RequestUserKey, user.ID)
+	}
 }
 
 func (c *BaseController) EndRequest(ctx iris.Context) {}
@@ -87,6 +93,37 @@ func (c *BaseController) SetToken(user *model.User) {
 	c.Session.Set("token", token)
 }
 
+// VisitorID 游客标识，不存在时生成并写入Cookie，有效期一年
+func (c *BaseController) VisitorID() string {
+	id := c.Ctx.GetCookie("visitor")
+	if len(id) == 32 {
+		return id
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	id = hex.EncodeToString(b)
+	c.Ctx.SetCookieKV("visitor", id, iris.CookieExpires(365*24*time.Hour))
+	return id
+}
+
+// LockArticle 当前访客无权查看时隐藏文章正文，密码文章的解锁状态保存在会话中
+func (c *BaseController) LockArticle(article *model.Article) {
+	unlocked := c.Session.GetBooleanDefault(unlockKey(article.ID), false)
+	if !c.Svc.CanViewArticle(article, c.UserID, unlocked) {
+		article.Lock()
+	}
+}
+
+func (c *BaseController) SetArticleUnlocked(id int64) {
+	c.Session.Set(unlockKey(id), true)
+}
+
+func unlockKey(id int64) string {
+	return "unlock_" + strconv.FormatInt(id, 10)
+}
+
 func (c *BaseController) ParseToken() (user *model.UserSession) {
 	user = new(model.UserSession)
 	tokenStr := c.Session.GetString("token")
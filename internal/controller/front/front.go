@@ -1,9 +1,12 @@
 package front
 
 import (
+	"encoding/xml"
 	"fmt"
 	"ginana-blog/internal/controller"
 	"ginana-blog/internal/model"
+	"github.com/griffin702/ginana/library/ecode"
+	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/mvc"
 	"strings"
 )
@@ -20,6 +23,13 @@ func (c *CFront) BeforeActivation(b mvc.BeforeActivation) {
 	b.Handle("GET", "/links.html", "GetLinks")
 	b.Handle("GET", "/album.html", "GetAlbums")
 	b.Handle("GET", "/s/{urlName:path}", "SpecialURL")
+	b.Handle("GET", "/c/{slug:string}", "CategoryPage")
+	b.Handle("GET", "/tag/{key:string}", "TagPage")
+	b.Handle("GET", "/archives", "GetArchives")
+	b.Handle("GET", "/archives/{year:int}/{month:int}", "ArchivePage")
+	b.Handle("GET", "/archives/{year:int}/{month:int}/{day:int}", "ArchivePage")
+	b.Handle("GET", "/feed.xml", "GetFeed")
+	b.Handle("GET", "/sitemap.xml", "GetSitemap")
 }
 
 func (c *CFront) setHeadMetas(params ...string) {
@@ -45,6 +55,28 @@ func (c *CFront) setHeadMetas(params ...string) {
 	}
 }
 
+// 将文章及其标签交给访问统计
+func (c *CFront) setStatArticle(article *model.Article) {
+	if article.Status != 0 {
+		return
+	}
+	c.Ctx.Values().Set(model.StatArticleKey, article.ID)
+	tagIDs := make([]int64, 0, len(article.Tags))
+	for _, tag := range article.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	c.Ctx.Values().Set(model.StatTagsKey, tagIDs)
+}
+
+// setBreadcrumbs 分类面包屑导航
+func (c *CFront) setBreadcrumbs(categoryID int64) (tree *model.CategoryTree, err error) {
+	if tree, err = c.Svc.GetCategoryTree(); err != nil {
+		return
+	}
+	c.Ctx.ViewData("breadcrumbs", tree.Breadcrumbs(categoryID))
+	return
+}
+
 func (c *CFront) Get() (err error) {
 	tags, err := c.Svc.GetTagsLimit6()
 	if err != nil {
@@ -68,17 +100,71 @@ func (c *CFront) GetArticleBy(id int64) (err error) {
 	if err != nil {
 		return
 	}
+	if !c.canPreview(article) {
+		return c.Hm.GetMessage(404, "404 not found")
+	}
+	if article.Status == 0 && c.Svc.AddArticleView(article.ID, c.GetClientIP(), c.Ctx.GetHeader("User-Agent")) {
+		article.Views++
+	}
+	c.setStatArticle(article)
+	c.LockArticle(article)
+	c.setNoindex(article)
+	if article.Reactions, err = c.Svc.GetArticleReactions(article.ID, c.UserID,
+		c.VisitorID(), c.GetClientIP()); err != nil {
+		return
+	}
 	c.Ctx.ViewData("data", article)
 	comments, err := c.Svc.GetComments(c.Pager, model.CommentQueryParam{ArticleID: id})
 	if err != nil {
 		return
 	}
 	c.Ctx.ViewData("comments", comments)
+	related, err := c.Svc.GetRelatedArticles(id, c.SiteOptions.GetRelatedSize())
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("related", related)
+	if _, err = c.setBreadcrumbs(article.CategoryID); err != nil {
+		return
+	}
 	c.setHeadMetas(article.Title)
 	c.Ctx.View("front/article.html")
 	return
 }
 
+// canPreview 草稿与回收站中的文章只有超级管理员与作者可以预览
+func (c *CFront) canPreview(article *model.Article) bool {
+	return article.Status == 0 || c.UserID == 1 || c.UserID > 0 && c.UserID == article.UserID
+}
+
+// setNoindex 非公开或未发布的文章不允许搜索引擎收录，静态站点生成时据此跳过
+func (c *CFront) setNoindex(article *model.Article) {
+	noindex := !article.IsPublic() || article.Locked || article.Status != 0
+	c.Ctx.ViewData("noindex", noindex)
+	if noindex {
+		c.Ctx.Header("X-Robots-Tag", "noindex")
+	}
+}
+
+// PostArticleByUnlock 校验访问密码，通过后本次会话内可查看全文
+func (c *CFront) PostArticleByUnlock(id int64) (err error) {
+	if err = c.Svc.UnlockArticle(id, c.Ctx.PostValue("password"), c.GetClientIP()); err != nil {
+		if ecode.Cause(err).Code() == 1012 {
+			c.ShowMsg(err.Error())
+		} else {
+			c.ShowMsg("访问密码不正确")
+		}
+		return nil
+	}
+	c.SetArticleUnlocked(id)
+	article, err := c.Svc.GetArticle(id)
+	if err != nil {
+		return
+	}
+	c.Ctx.Redirect(article.Link())
+	return
+}
+
 func (c *CFront) GetLife() (err error) {
 	articles, err := c.Svc.GetArticles(c.Pager)
 	if err != nil {
@@ -97,22 +183,143 @@ func (c *CFront) GetCategorys() (err error) {
 		return
 	}
 	c.Ctx.ViewData("data", tags)
+	tree, err := c.Svc.GetCategoryTree()
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("categories", tree.Roots)
 	c.setHeadMetas("归类归档")
 	c.Ctx.View("front/category.html")
 	return
 }
 
 func (c *CFront) GetCategoryBy(id int64) (err error) {
-	articles, err := c.Svc.GetArticles(c.Pager, model.ArticleQueryParam{TagID: id})
+	tag, err := c.Svc.GetTag(id)
+	if err != nil {
+		return c.Hm.GetMessage(404, "404 not found")
+	}
+	c.Ctx.Redirect(tag.URL(), iris.StatusMovedPermanently)
+	return
+}
+
+// TagPage 标签页，支持URL别名、标签名与同义词访问，非规范地址跳转到规范地址
+func (c *CFront) TagPage() (err error) {
+	key := c.Ctx.Params().GetStringDefault("key", "")
+	tag, err := c.Svc.ResolveTag(key)
+	if err != nil {
+		return c.Hm.GetMessage(404, "404 not found")
+	}
+	canonical := tag.Slug
+	if canonical == "" {
+		canonical = tag.Name
+	}
+	if key != canonical {
+		c.Ctx.Redirect(tag.URL(), iris.StatusMovedPermanently)
+		return
+	}
+	return c.tagArticles(tag)
+}
+
+func (c *CFront) tagArticles(tag *model.Tag) (err error) {
+	articles, err := c.Svc.GetArticles(c.Pager, model.ArticleQueryParam{TagID: tag.ID})
 	if err != nil {
 		return
 	}
+	c.Ctx.Values().Set(model.StatTagsKey, []int64{tag.ID})
+	c.Ctx.ViewData("tag", tag)
 	c.Ctx.ViewData("data", articles)
-	c.setHeadMetas("归类归档")
+	desc := tag.Description
+	if desc == "" {
+		desc = c.SiteOptions.Description
+	}
+	c.setHeadMetas(tag.Name, c.SiteOptions.Keywords, desc)
 	c.Ctx.View("front/categoryList.html")
 	return
 }
 
+func (c *CFront) CategoryPage() (err error) {
+	slug := c.Ctx.Params().GetStringDefault("slug", "")
+	category, err := c.Svc.GetCategoryBySlug(slug)
+	if err != nil {
+		return c.Hm.GetMessage(404, "404 not found")
+	}
+	tree, err := c.setBreadcrumbs(category.ID)
+	if err != nil {
+		return
+	}
+	if node, ok := tree.Index[category.ID]; ok {
+		category = node
+	}
+	articles, err := c.Svc.GetArticles(c.Pager, model.ArticleQueryParam{CategoryIDs: tree.Descendants(category.ID)})
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("category", category)
+	c.Ctx.ViewData("data", articles)
+	desc := category.Description
+	if desc == "" {
+		desc = c.SiteOptions.Description
+	}
+	c.setHeadMetas(category.Name, c.SiteOptions.Keywords, desc)
+	c.Ctx.View("front/categoryPage.html")
+	return
+}
+
+func (c *CFront) GetArchives() (err error) {
+	years, err := c.Svc.GetArchives()
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("data", years)
+	c.setHeadMetas("文章归档")
+	c.Ctx.View("front/archives.html")
+	return
+}
+
+// ArchivePage 按月或按日列出文章
+func (c *CFront) ArchivePage() (err error) {
+	params := c.Ctx.Params()
+	year, month, day := params.GetIntDefault("year", 0), params.GetIntDefault("month", 0), params.GetIntDefault("day", 0)
+	archive := &model.ArchiveMonth{Year: year, Month: month}
+	start, end := archive.Range()
+	if month < 1 || month > 12 || start.Year() != year || day < 0 || day > end.AddDate(0, 0, -1).Day() {
+		return c.Hm.GetMessage(404, "404 not found")
+	}
+	title := fmt.Sprintf("%d年%d月", year, month)
+	if day > 0 {
+		start = start.AddDate(0, 0, day-1)
+		end = start.AddDate(0, 0, 1)
+		title = fmt.Sprintf("%s%d日", title, day)
+	}
+	articles, err := c.Svc.GetArticles(c.Pager, model.ArticleQueryParam{StartAt: start, EndAt: end})
+	if err != nil {
+		return
+	}
+	calendar, err := c.Svc.GetCalendar(year, month)
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("calendar", calendar)
+	c.Ctx.ViewData("archive", archive)
+	c.Ctx.ViewData("archiveTitle", title)
+	c.Ctx.ViewData("data", articles)
+	c.setHeadMetas(title + "的文章")
+	c.Ctx.View("front/archiveList.html")
+	return
+}
+
+func (c *CFront) GetSeriesBy(id int64) (err error) {
+	series, err := c.Svc.GetPublicSeries(id)
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("data", series)
+	c.Ctx.ViewData("articles", series.Articles)
+	c.setHeadMetas(series.Title, c.SiteOptions.Keywords, series.Description)
+	c.Ctx.View("front/series.html")
+	return
+}
+
 func (c *CFront) GetMoods() (err error) {
 	moods, err := c.Svc.GetMoods(c.Pager)
 	if err != nil {
@@ -172,16 +379,65 @@ func (c *CFront) SpecialURL() (err error) {
 		return c.Hm.GetMessage(404, "404 not found")
 	}
 	article, err := c.Svc.GetArticleByUrlName(urlName)
-	if err != nil {
+	if err != nil || !c.canPreview(article) {
 		return c.Hm.GetMessage(404, "404 not found")
 	}
+	if article.Status == 0 && c.Svc.AddArticleView(article.ID, c.GetClientIP(), c.Ctx.GetHeader("User-Agent")) {
+		article.Views++
+	}
+	c.setStatArticle(article)
+	c.LockArticle(article)
+	c.setNoindex(article)
+	if article.Reactions, err = c.Svc.GetArticleReactions(article.ID, c.UserID,
+		c.VisitorID(), c.GetClientIP()); err != nil {
+		return
+	}
 	c.Ctx.ViewData("data", article)
 	comments, err := c.Svc.GetComments(c.Pager, model.CommentQueryParam{ArticleID: article.ID})
 	if err != nil {
 		return
 	}
 	c.Ctx.ViewData("comments", comments)
+	related, err := c.Svc.GetRelatedArticles(article.ID, c.SiteOptions.GetRelatedSize())
+	if err != nil {
+		return
+	}
+	c.Ctx.ViewData("related", related)
+	if _, err = c.setBreadcrumbs(article.CategoryID); err != nil {
+		return
+	}
 	c.setHeadMetas(article.Title)
 	c.Ctx.View("front/article.html")
 	return
 }
+
+func (c *CFront) GetFeed() (err error) {
+	c.Pager.Page = 1
+	c.Pager.PageSize = 20
+	articles, err := c.Svc.GetArticles(c.Pager, model.ArticleQueryParam{Order: "id desc", Public: true})
+	if err != nil {
+		return
+	}
+	data, err := xml.Marshal(model.NewRSS(c.SiteOptions, articles.List))
+	if err != nil {
+		return
+	}
+	c.Ctx.ContentType("application/rss+xml")
+	_, err = c.Ctx.Write(append([]byte(xml.Header), data...))
+	return
+}
+
+// GetSitemap 站点地图，仅收录公开文章
+func (c *CFront) GetSitemap() (err error) {
+	articles, err := c.Svc.GetSitemapArticles()
+	if err != nil {
+		return
+	}
+	data, err := xml.Marshal(model.NewSitemap(c.SiteOptions, articles))
+	if err != nil {
+		return
+	}
+	c.Ctx.ContentType("application/xml")
+	_, err = c.Ctx.Write(append([]byte(xml.Header), data...))
+	return
+}
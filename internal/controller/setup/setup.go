@@ -0,0 +1,64 @@
+package setup
+
+import (
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/controller"
+	"ginana-blog/internal/model"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// CSetup 首次安装向导，仅在尚未创建管理员时可用
+type CSetup struct {
+	controller.BaseController
+}
+
+func (c *CSetup) Get() (err error) {
+	if !c.Svc.NeedSetup() {
+		return c.Hm.GetMessage(404, "404 not found")
+	}
+	scheme := "http"
+	if c.Ctx.Request().TLS != nil {
+		scheme = "https"
+	}
+	c.Ctx.ViewData("siteURL", scheme+"://"+c.Ctx.Host())
+	c.Ctx.ViewData("themes", c.themes())
+	c.Ctx.ViewData("title", "安装向导 - GiNana")
+	c.Ctx.View("setup/index.html")
+	return
+}
+
+func (c *CSetup) Post() (err error) {
+	if !c.Svc.NeedSetup() {
+		return c.Hm.GetMessage(404, "404 not found")
+	}
+	req := new(model.SetupReq)
+	if err = c.Ctx.ReadForm(req); err != nil {
+		return
+	}
+	if err = c.Valid(req); err != nil {
+		return
+	}
+	if _, err = c.Svc.Setup(req); err != nil {
+		return
+	}
+	c.Ctx.ViewData("title", "安装完成 - GiNana")
+	c.ShowMsg("安装完成，请使用管理员账号登录", "/")
+	return
+}
+
+// themes 静态目录下可用的主题
+func (c *CSetup) themes() (list []string) {
+	_, dir := config.StaticRoot(c.Config.StaticDir)
+	files, _ := ioutil.ReadDir(filepath.Join(dir, "theme"))
+	for _, f := range files {
+		if f.IsDir() && !strings.HasPrefix(f.Name(), ".") {
+			list = append(list, f.Name())
+		}
+	}
+	if len(list) == 0 {
+		list = append(list, "main")
+	}
+	return
+}
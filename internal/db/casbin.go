@@ -14,7 +14,7 @@ import (
 // NewCasbin after Service, and SetEnforcer for service
 func NewCasbin(svc service.Service, cfg *config.Config) (ef *casbin.SyncedEnforcer, err error) {
 	key := "casbin.toml"
-	if err = paladin.Get(key).UnmarshalTOML(cfg); err != nil {
+	if err = config.Load(key, cfg); err != nil {
 		return
 	}
 	ef, err = database.NewCasbinConn(svc, cfg.ConfigPath, cfg.Casbin)
@@ -49,6 +49,10 @@ func WatchCasbinConfig(e *casbin.SyncedEnforcer, c *database.CasbinConfig, key s
 			log.Printf("Loading Config error(%v)", err)
 			continue
 		}
+		if err := config.Global().Override(); err != nil {
+			log.Printf("Loading Config error(%v)", err)
+			continue
+		}
 		if c.AutoLoad != autoLoad {
 			if c.AutoLoad {
 				_ = e.InitWithModelAndAdapter(e.GetModel(), e.GetAdapter())
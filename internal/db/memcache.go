@@ -3,14 +3,13 @@ package db
 import (
 	"ginana-blog/internal/config"
 	"github.com/griffin702/ginana/library/cache/memcache"
-	"github.com/griffin702/ginana/library/conf/paladin"
 )
 
 func NewMC(cfg *config.Config) (mc memcache.Memcache, err error) {
 	key := "memcache.toml"
-	if err = paladin.Get(key).UnmarshalTOML(cfg); err != nil {
+	if err = config.Load(key, cfg); err != nil {
 		return
 	}
-	mc = memcache.New(cfg.Memcache)
+	mc = &metricsCache{Memcache: memcache.New(cfg.Memcache)}
 	return
 }
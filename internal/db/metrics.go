@@ -0,0 +1,68 @@
+package db
+
+import (
+	"ginana-blog/internal/metrics"
+	mc "github.com/bradfitz/gomemcache/memcache"
+	"github.com/griffin702/ginana/library/cache/memcache"
+	"github.com/jinzhu/gorm"
+	"strings"
+	"time"
+)
+
+const metricsStartKey = "metrics:start_time"
+
+// registerMetrics 通过 GORM 回调记录每次数据库操作的耗时
+func registerMetrics(db *gorm.DB) {
+	cb := db.Callback()
+	cb.Create().Before("gorm:begin_transaction").Register("metrics:before_create", metricsBefore)
+	cb.Create().After("gorm:commit_or_rollback_transaction").Register("metrics:after_create", metricsAfter("create"))
+	cb.Update().Before("gorm:begin_transaction").Register("metrics:before_update", metricsBefore)
+	cb.Update().After("gorm:commit_or_rollback_transaction").Register("metrics:after_update", metricsAfter("update"))
+	cb.Delete().Before("gorm:begin_transaction").Register("metrics:before_delete", metricsBefore)
+	cb.Delete().After("gorm:commit_or_rollback_transaction").Register("metrics:after_delete", metricsAfter("delete"))
+	cb.Query().Before("gorm:query").Register("metrics:before_query", metricsBefore)
+	cb.Query().After("gorm:after_query").Register("metrics:after_query", metricsAfter("query"))
+	cb.RowQuery().Before("gorm:row_query").Register("metrics:before_row_query", metricsBefore)
+	cb.RowQuery().After("gorm:row_query").Register("metrics:after_row_query", metricsAfter("row_query"))
+}
+
+func metricsBefore(scope *gorm.Scope) {
+	scope.InstanceSet(metricsStartKey, time.Now())
+}
+
+func metricsAfter(operation string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		v, ok := scope.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		table := ""
+		if scope.Value != nil {
+			table = scope.TableName()
+		}
+		metrics.DBQueryDuration.Since(start, operation, table)
+	}
+}
+
+// metricsCache 记录缓存命中情况，按 HelperMap 中的缓存名称统计，忽略键中的参数部分
+type metricsCache struct {
+	memcache.Memcache
+}
+
+func (c *metricsCache) Get(key string, obj interface{}) (err error) {
+	err = c.Memcache.Get(key, obj)
+	name := strings.SplitN(key, "_", 2)[0]
+	switch err {
+	case nil:
+		metrics.CacheRequests.Inc(name, "hit")
+	case mc.ErrCacheMiss:
+		metrics.CacheRequests.Inc(name, "miss")
+	default:
+		metrics.CacheRequests.Inc(name, "error")
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"ginana-blog/internal/trace"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// registerQueryLog 以 JSON 行记录数据库操作并带上请求ID，出错的操作总会记录，
+// 开启 MySQL.Debug 时记录全部操作及参数，取代 gorm 自带的调试输出
+func registerQueryLog(db *gorm.DB, debug bool) {
+	cb := db.Callback()
+	cb.Create().After("metrics:after_create").Register("trace:after_create", queryLog("create", debug))
+	cb.Update().After("metrics:after_update").Register("trace:after_update", queryLog("update", debug))
+	cb.Delete().After("metrics:after_delete").Register("trace:after_delete", queryLog("delete", debug))
+	cb.Query().After("metrics:after_query").Register("trace:after_query", queryLog("query", debug))
+	cb.RowQuery().After("metrics:after_row_query").Register("trace:after_row_query", queryLog("row_query", debug))
+}
+
+func queryLog(operation string, debug bool) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		err := scope.DB().Error
+		if err == gorm.ErrRecordNotFound {
+			err = nil
+		}
+		if err == nil && !debug {
+			return
+		}
+		v, _ := scope.Get(trace.GormKey)
+		id, _ := v.(string)
+		fields := trace.Fields{
+			"request_id": id,
+			"operation":  operation,
+			"sql":        scope.SQL,
+			"rows":       scope.DB().RowsAffected,
+		}
+		if scope.Value != nil {
+			fields["table"] = scope.TableName()
+		}
+		if v, ok := scope.InstanceGet(metricsStartKey); ok {
+			if start, ok := v.(time.Time); ok {
+				fields["latency_ms"] = float64(time.Since(start).Microseconds()) / 1000
+			}
+		}
+		if err != nil {
+			fields["error"] = err
+			trace.Error("db", fields)
+			return
+		}
+		fields["vars"] = scope.SQLVars
+		trace.Info("db", fields)
+	}
+}
@@ -0,0 +1,352 @@
+// Package exporter 将文章、心情、标签、分类与评论导出为带 YAML front matter 的
+// Markdown 文件，连同引用的上传文件打包为 zip，便于纳入 git 备份
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/service"
+	"gopkg.in/yaml.v2"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 导出文件中的时间格式，可被导入器解析
+const timeLayout = time.RFC3339
+
+var articleStatus = map[int8]string{0: "published", 1: "draft", 2: "trash"}
+
+type articleMeta struct {
+	ID         int64          `yaml:"id"`
+	Title      string         `yaml:"title"`
+	Slug       string         `yaml:"slug,omitempty"`
+	Urltype    int8           `yaml:"urltype,omitempty"`
+	Date       string         `yaml:"date"`
+	Updated    string         `yaml:"updated"`
+	Status     string         `yaml:"status"`
+	Draft      bool           `yaml:"draft,omitempty"` // 仅草稿为 true，状态以 status 为准
+	Author     string         `yaml:"author,omitempty"`
+	Category   string         `yaml:"category,omitempty"`
+	Tags       []string       `yaml:"tags,omitempty"`
+	Cover      string         `yaml:"cover,omitempty"`
+	Color      string         `yaml:"color,omitempty"`
+	Istop      int8           `yaml:"istop,omitempty"`
+	ComeFrom   string         `yaml:"come_from,omitempty"`
+	Visibility int8           `yaml:"visibility,omitempty"`
+	RoleIDs    []int64        `yaml:"role_ids,omitempty"`
+	Password   string         `yaml:"password,omitempty"` // 访问密码的 bcrypt 哈希
+	Views      int64          `yaml:"views"`
+	Likes      int64          `yaml:"likes"`
+	Comments   []*commentMeta `yaml:"comments,omitempty"`
+}
+
+type commentMeta struct {
+	ID       int64  `yaml:"id"`
+	ReplyTo  int64  `yaml:"reply_to,omitempty"` // 被回复的评论
+	Thread   int64  `yaml:"thread,omitempty"`   // 所在的顶层评论
+	Author   string `yaml:"author"`
+	Nickname string `yaml:"nickname,omitempty"`
+	Date     string `yaml:"date"`
+	IP       string `yaml:"ip,omitempty"`
+	Content  string `yaml:"content"`
+}
+
+type moodMeta struct {
+	ID    int64  `yaml:"id"`
+	Date  string `yaml:"date"`
+	Cover string `yaml:"cover,omitempty"`
+}
+
+type tagMeta struct {
+	ID      int64    `yaml:"id"`
+	Name    string   `yaml:"name"`
+	Slug    string   `yaml:"slug,omitempty"`
+	Aliases []string `yaml:"aliases,omitempty"`
+	Cover   string   `yaml:"cover,omitempty"`
+	Date    string   `yaml:"date"`
+}
+
+type categoryMeta struct {
+	ID     int64  `yaml:"id"`
+	Name   string `yaml:"name"`
+	Slug   string `yaml:"slug"`
+	Parent string `yaml:"parent,omitempty"`
+	Sort   int    `yaml:"sort"`
+	Cover  string `yaml:"cover,omitempty"`
+	Date   string `yaml:"date"`
+}
+
+type seriesMeta struct {
+	ID       int64   `yaml:"id"`
+	Title    string  `yaml:"title"`
+	Cover    string  `yaml:"cover,omitempty"`
+	Date     string  `yaml:"date"`
+	Articles []int64 `yaml:"articles,omitempty"` // 按顺序排列的文章ID
+}
+
+type linkCommentsMeta struct {
+	Title    string         `yaml:"title"`
+	Comments []*commentMeta `yaml:"comments"`
+}
+
+// 文件名中不允许的字符
+var unsafeName = regexp.MustCompile(`[\\/:*?"<>|\s]+`)
+
+// Run 读取全部内容并写入 zip
+func Run(svc service.Service, w io.Writer, staticDir string) (err error) {
+	data, err := svc.GetExportData()
+	if err != nil {
+		return
+	}
+	return Write(w, data, staticDir)
+}
+
+// Write 将导出内容写入 zip，引用的上传文件保存在 static 目录下
+func Write(w io.Writer, data *model.ExportData, staticDir string) (err error) {
+	e := &writer{
+		zw:      zip.NewWriter(w),
+		uploads: make(map[string]bool),
+	}
+	e.prefix, e.dir = config.StaticRoot(staticDir)
+	e.upload = regexp.MustCompile(regexp.QuoteMeta(e.prefix) + `/upload/[^\s"'()<>\[\]]+`)
+	categories := make(map[int64]*model.Category)
+	for _, c := range data.Categories {
+		categories[c.ID] = c
+	}
+	for _, a := range data.Articles {
+		if err = e.article(a, categories[a.CategoryID], data.Comments[a.ID]); err != nil {
+			return
+		}
+	}
+	if err = e.linkComments(data.Comments[0]); err != nil {
+		return
+	}
+	for _, m := range data.Moods {
+		if err = e.mood(m); err != nil {
+			return
+		}
+	}
+	for _, t := range data.Tags {
+		if err = e.tag(t); err != nil {
+			return
+		}
+	}
+	for _, c := range data.Categories {
+		if err = e.category(c, categories[c.ParentID]); err != nil {
+			return
+		}
+	}
+	seriesArticles := make(map[int64][]int64)
+	for _, sa := range data.SeriesArticles {
+		seriesArticles[sa.SeriesID] = append(seriesArticles[sa.SeriesID], sa.ArticleID)
+	}
+	for _, series := range data.Series {
+		if err = e.series(series, seriesArticles[series.ID]); err != nil {
+			return
+		}
+	}
+	if err = e.writeUploads(); err != nil {
+		return
+	}
+	return e.zw.Close()
+}
+
+type writer struct {
+	zw      *zip.Writer
+	prefix  string // 静态文件URL前缀
+	dir     string // 静态文件目录
+	upload  *regexp.Regexp
+	uploads map[string]bool // 引用的上传文件URL
+}
+
+func (e *writer) article(a *model.Article, category *model.Category, comments []*model.Comment) error {
+	meta := &articleMeta{
+		ID:         a.ID,
+		Title:      a.Title,
+		Slug:       a.Urlname,
+		Urltype:    a.Urltype,
+		Date:       a.CreatedAt.Format(timeLayout),
+		Updated:    a.UpdatedAt.Format(timeLayout),
+		Status:     articleStatus[a.Status],
+		Draft:      a.Status == 1,
+		Cover:      a.Cover,
+		Color:      a.Color,
+		Istop:      a.Istop,
+		ComeFrom:   a.ComeFrom,
+		Visibility: a.Visibility,
+		RoleIDs:    model.ParseIDs(a.RoleIDs),
+		Password:   a.Password,
+		Views:      a.Views,
+		Likes:      a.Likes,
+		Comments:   e.comments(comments),
+	}
+	if a.User != nil {
+		meta.Author = a.User.Username
+	}
+	if category != nil {
+		meta.Category = categoryName(category)
+	}
+	for _, t := range a.Tags {
+		meta.Tags = append(meta.Tags, t.Name)
+	}
+	e.scan(a.Cover, a.Content)
+	name := fmt.Sprintf("%d", a.ID)
+	if a.Urlname != "" {
+		name += "-" + unsafeName.ReplaceAllString(a.Urlname, "-")
+	}
+	return e.markdown(fmt.Sprintf("articles/%s/%s.md", a.CreatedAt.Format("2006"), name), meta, a.Content)
+}
+
+func (e *writer) comments(list []*model.Comment) (res []*commentMeta) {
+	for _, c := range list {
+		meta := &commentMeta{
+			ID:      c.ID,
+			ReplyTo: c.ReplyPK,
+			Thread:  c.ReplyFK,
+			Date:    c.CreatedAt.Format(timeLayout),
+			IP:      c.IPAddress,
+			Content: c.Content,
+		}
+		if c.User != nil {
+			meta.Author = c.User.Username
+			meta.Nickname = c.User.Nickname
+		}
+		e.scan(c.Content)
+		res = append(res, meta)
+	}
+	return
+}
+
+func (e *writer) linkComments(list []*model.Comment) error {
+	if len(list) == 0 {
+		return nil
+	}
+	return e.markdown("links.md", &linkCommentsMeta{Title: "友情链接", Comments: e.comments(list)}, "")
+}
+
+func (e *writer) mood(m *model.Mood) error {
+	e.scan(m.Cover, m.Content)
+	if m.Cover != "" {
+		e.scan(m.ChangetoSmall())
+	}
+	meta := &moodMeta{ID: m.ID, Date: m.CreatedAt.Format(timeLayout), Cover: m.Cover}
+	return e.markdown(fmt.Sprintf("moods/%s/%d.md", m.CreatedAt.Format("2006"), m.ID), meta, m.Content)
+}
+
+func (e *writer) tag(t *model.Tag) error {
+	meta := &tagMeta{ID: t.ID, Name: t.Name, Slug: t.Slug, Cover: t.Cover, Date: t.CreatedAt.Format(timeLayout)}
+	for _, a := range t.Aliases {
+		meta.Aliases = append(meta.Aliases, a.Name)
+	}
+	e.scan(t.Cover)
+	name := t.Slug
+	if name == "" {
+		name = fmt.Sprintf("%d-%s", t.ID, unsafeName.ReplaceAllString(t.Name, "-"))
+	}
+	return e.markdown("tags/"+name+".md", meta, t.Description)
+}
+
+func (e *writer) category(c, parent *model.Category) error {
+	meta := &categoryMeta{ID: c.ID, Name: c.Name, Slug: c.Slug, Sort: c.Sort, Cover: c.Cover, Date: c.CreatedAt.Format(timeLayout)}
+	if parent != nil {
+		meta.Parent = categoryName(parent)
+	}
+	e.scan(c.Cover)
+	return e.markdown("categories/"+categoryName(c)+".md", meta, c.Description)
+}
+
+// categoryName 分类的文件名与引用名，早期没有别名的分类使用 ID-名称
+func categoryName(c *model.Category) string {
+	if c.Slug != "" {
+		return c.Slug
+	}
+	return fmt.Sprintf("%d-%s", c.ID, unsafeName.ReplaceAllString(c.Name, "-"))
+}
+
+func (e *writer) series(s *model.Series, articles []int64) error {
+	meta := &seriesMeta{ID: s.ID, Title: s.Title, Cover: s.Cover, Date: s.CreatedAt.Format(timeLayout), Articles: articles}
+	e.scan(s.Cover)
+	name := fmt.Sprintf("%d-%s", s.ID, unsafeName.ReplaceAllString(s.Title, "-"))
+	return e.markdown("series/"+name+".md", meta, s.Description)
+}
+
+// markdown 写入 front matter 与正文
+func (e *writer) markdown(name string, meta interface{}, body string) (err error) {
+	fm, err := yaml.Marshal(meta)
+	if err != nil {
+		return
+	}
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(fm)
+	buf.WriteString("---\n")
+	if body != "" {
+		buf.WriteString("\n")
+		buf.WriteString(strings.TrimRight(body, "\n"))
+		buf.WriteString("\n")
+	}
+	return e.write(name, time.Now(), &buf)
+}
+
+func (e *writer) write(name string, modified time.Time, r io.Reader) (err error) {
+	fh := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	fh.SetModTime(modified)
+	w, err := e.zw.CreateHeader(fh)
+	if err != nil {
+		return
+	}
+	_, err = io.Copy(w, r)
+	return
+}
+
+// scan 记录内容中引用的上传文件
+func (e *writer) scan(list ...string) {
+	for _, s := range list {
+		for _, u := range e.upload.FindAllString(s, -1) {
+			e.uploads[u] = true
+		}
+	}
+}
+
+// writeUploads 写入引用的上传文件，已不存在的文件忽略
+func (e *writer) writeUploads() (err error) {
+	var list []string
+	for u := range e.uploads {
+		list = append(list, u)
+	}
+	sort.Strings(list)
+	for _, u := range list {
+		rel := path.Clean(strings.TrimPrefix(u, e.prefix))
+		if !strings.HasPrefix(rel, "/upload/") {
+			continue
+		}
+		if err = e.writeFile(filepath.Join(e.dir, filepath.FromSlash(rel)), "static"+rel); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (e *writer) writeFile(file, name string) (err error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		return
+	}
+	return e.write(name, fi.ModTime(), f)
+}
@@ -0,0 +1,87 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"ginana-blog/internal/model"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	upload := filepath.Join(dir, "upload", "image", "20200101")
+	if err = os.MkdirAll(upload, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.png", "m.jpg", "m_small.jpg"} {
+		if err = ioutil.WriteFile(filepath.Join(upload, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := &model.ExportData{
+		Articles: []*model.Article{{
+			ID: 7, Title: "标题", Urlname: "hello/world", Urltype: 1, Status: 1,
+			CreatedAt: created, UpdatedAt: created, CategoryID: 3,
+			Content: "正文 ![a](/static/upload/image/20200101/a.png) ![b](/static/upload/missing.png)",
+			User:    &model.User{Username: "admin"},
+			Tags:    []*model.Tag{{Name: "Go"}},
+		}, {
+			ID: 8, Title: "加锁", Status: 2, Visibility: model.VisibilityPassword, Password: "$2a$10$hash",
+			CreatedAt: created, UpdatedAt: created,
+		}},
+		Comments: map[int64][]*model.Comment{
+			7: {{ID: 1, Content: "评论", CreatedAt: created, User: &model.User{Username: "u", Nickname: "路人"}}},
+		},
+		Moods:          []*model.Mood{{ID: 2, Content: "心情", Cover: "/static/upload/image/20200101/m.jpg", CreatedAt: created}},
+		Tags:           []*model.Tag{{ID: 1, Name: "Go", Slug: "go", Description: "描述", Aliases: []*model.TagAlias{{Name: "golang"}}}},
+		Categories:     []*model.Category{{ID: 3, Name: "技术", Slug: "tech"}, {ID: 4, Name: "旧 分类", ParentID: 3}, {ID: 5, Name: "另一个"}},
+		Series:         []*model.Series{{ID: 6, Title: "入门 系列", Description: "系列描述", CreatedAt: created}},
+		SeriesArticles: []*model.SeriesArticle{{SeriesID: 6, ArticleID: 8, Sort: 1}, {SeriesID: 6, ArticleID: 7, Sort: 2}},
+	}
+
+	Convey("Write", t, func() {
+		var buf bytes.Buffer
+		So(Write(&buf, data, "static:"+dir), ShouldBeNil)
+		r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		So(err, ShouldBeNil)
+		files := make(map[string]string)
+		for _, f := range r.File {
+			rc, err := f.Open()
+			So(err, ShouldBeNil)
+			b, _ := ioutil.ReadAll(rc)
+			rc.Close()
+			files[f.Name] = string(b)
+		}
+		So(files, ShouldContainKey, "articles/2020/7-hello-world.md")
+		So(files, ShouldContainKey, "moods/2020/2.md")
+		So(files, ShouldContainKey, "tags/go.md")
+		So(files, ShouldContainKey, "categories/tech.md")
+		So(files["static/upload/image/20200101/a.png"], ShouldEqual, "a.png")
+		So(files["static/upload/image/20200101/m_small.jpg"], ShouldEqual, "m_small.jpg")
+		So(files, ShouldNotContainKey, "static/upload/missing.png")
+		So(files["articles/2020/7-hello-world.md"], ShouldStartWith,
+			"---\nid: 7\ntitle: 标题\nslug: hello/world\nurltype: 1\ndate: \"2020-01-02T03:04:05Z\"\n")
+		So(files["articles/2020/7-hello-world.md"], ShouldContainSubstring, "status: draft\ndraft: true\nauthor: admin\ncategory: tech\ntags:\n- Go\n")
+		So(files["articles/2020/7-hello-world.md"], ShouldContainSubstring, "comments:\n- id: 1\n  author: u\n  nickname: 路人\n")
+		So(files["articles/2020/7-hello-world.md"], ShouldEndWith, "---\n\n正文 ![a](/static/upload/image/20200101/a.png) ![b](/static/upload/missing.png)\n")
+		So(files["articles/2020/8.md"], ShouldContainSubstring, "status: trash\n")
+		So(files["articles/2020/8.md"], ShouldNotContainSubstring, "draft:")
+		So(files["articles/2020/8.md"], ShouldContainSubstring, "visibility: 1\npassword: $2a$10$hash\n")
+		So(files["categories/4-旧-分类.md"], ShouldContainSubstring, "parent: tech\n")
+		So(files, ShouldContainKey, "categories/5-另一个.md")
+		So(files["series/6-入门-系列.md"], ShouldContainSubstring, "articles:\n- 8\n- 7\n")
+		So(files["series/6-入门-系列.md"], ShouldEndWith, "---\n\n系列描述\n")
+		So(files["tags/go.md"], ShouldContainSubstring, "aliases:\n- golang\n")
+		So(files["tags/go.md"], ShouldEndWith, "---\n\n描述\n")
+	})
+}
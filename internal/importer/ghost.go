@@ -0,0 +1,203 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"ginana-blog/internal/model"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Ghost 导出格式，新版本外层为 {"db":[{"data":{}}]}，旧版本直接为 {"data":{}}
+type ghostExport struct {
+	DB   []ghostDB  `json:"db"`
+	Data *ghostData `json:"data"`
+}
+
+type ghostDB struct {
+	Data *ghostData `json:"data"`
+}
+
+type ghostData struct {
+	Posts        []ghostPost     `json:"posts"`
+	Tags         []ghostTag      `json:"tags"`
+	Users        []ghostUser     `json:"users"`
+	PostsTags    []ghostPostTag  `json:"posts_tags"`
+	PostsAuthors []ghostPostUser `json:"posts_authors"`
+}
+
+type ghostPost struct {
+	ID           ghostID `json:"id"`
+	Title        string  `json:"title"`
+	Slug         string  `json:"slug"`
+	Markdown     string  `json:"markdown"`
+	Mobiledoc    string  `json:"mobiledoc"`
+	HTML         string  `json:"html"`
+	FeatureImage string  `json:"feature_image"`
+	Image        string  `json:"image"`
+	Type         string  `json:"type"`
+	Page         bool    `json:"page"`
+	Status       string  `json:"status"`
+	Visibility   string  `json:"visibility"`
+	AuthorID     ghostID `json:"author_id"`
+	CreatedAt    string  `json:"created_at"`
+	PublishedAt  string  `json:"published_at"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+type ghostTag struct {
+	ID          ghostID `json:"id"`
+	Name        string  `json:"name"`
+	Slug        string  `json:"slug"`
+	Description string  `json:"description"`
+}
+
+type ghostUser struct {
+	ID    ghostID `json:"id"`
+	Name  string  `json:"name"`
+	Slug  string  `json:"slug"`
+	Email string  `json:"email"`
+}
+
+type ghostPostTag struct {
+	PostID ghostID `json:"post_id"`
+	TagID  ghostID `json:"tag_id"`
+}
+
+type ghostPostUser struct {
+	PostID   ghostID `json:"post_id"`
+	AuthorID ghostID `json:"author_id"`
+}
+
+// ghostID 旧版本为数字，新版本为字符串
+type ghostID string
+
+func (id *ghostID) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case string:
+		*id = ghostID(v)
+	case float64:
+		*id = ghostID(fmt.Sprintf("%.0f", v))
+	}
+	return nil
+}
+
+// 新版本导出中站内链接以占位符代替站点地址
+var ghostURL = strings.NewReplacer("__GHOST_URL__", "")
+
+// mobiledoc 中的 Markdown 卡片
+type ghostMobiledoc struct {
+	Cards [][]json.RawMessage `json:"cards"`
+}
+
+func parseGhost(path string) (src *source, err error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var export ghostExport
+	if err = json.Unmarshal(b, &export); err != nil {
+		return
+	}
+	gd := export.Data
+	if len(export.DB) > 0 {
+		gd = export.DB[0].Data
+	}
+	if gd == nil {
+		return nil, fmt.Errorf("%s 不是 Ghost 导出文件", path)
+	}
+	data := &model.ImportData{Format: model.ImportGhost}
+	src = &source{data: data, roots: []string{filepath.Dir(path)}, dirs: make(map[*model.ImportPost][]string)}
+	for _, u := range gd.Users {
+		data.Users = append(data.Users, &model.ImportUser{
+			Key: string(u.ID), Username: u.Slug, Nickname: u.Name, Email: u.Email,
+		})
+	}
+	tagNames := make(map[ghostID]string)
+	for _, t := range gd.Tags {
+		tagNames[t.ID] = t.Name
+		// # 开头的是内部标签
+		if len(t.Name) > 0 && t.Name[0] != '#' {
+			data.Tags = append(data.Tags, &model.ImportTag{Name: t.Name, Slug: t.Slug, Description: t.Description})
+		}
+	}
+	postTags := make(map[ghostID][]string)
+	for _, pt := range gd.PostsTags {
+		if name := tagNames[pt.TagID]; len(name) > 0 && name[0] != '#' {
+			postTags[pt.PostID] = append(postTags[pt.PostID], name)
+		}
+	}
+	authors := make(map[ghostID]ghostID)
+	for _, pa := range gd.PostsAuthors {
+		if _, ok := authors[pa.PostID]; !ok {
+			authors[pa.PostID] = pa.AuthorID
+		}
+	}
+	pages := 0
+	for _, gp := range gd.Posts {
+		if gp.Page || gp.Type == "page" {
+			pages++
+			continue
+		}
+		p := &model.ImportPost{
+			Key:       string(gp.ID),
+			Source:    "post " + gp.Slug,
+			Title:     gp.Title,
+			Slug:      gp.Slug,
+			Content:   ghostURL.Replace(ghostContent(gp)),
+			Cover:     ghostURL.Replace(gp.FeatureImage),
+			Author:    string(gp.AuthorID),
+			Draft:     gp.Status != "published",
+			CreatedAt: parseTime(gp.PublishedAt),
+			UpdatedAt: parseTime(gp.UpdatedAt),
+			Tags:      postTags[gp.ID],
+		}
+		if p.Cover == "" {
+			p.Cover = ghostURL.Replace(gp.Image)
+		}
+		if id, ok := authors[gp.ID]; ok {
+			p.Author = string(id)
+		}
+		if p.CreatedAt.IsZero() {
+			p.CreatedAt = parseTime(gp.CreatedAt)
+		}
+		if gp.Visibility != "" && gp.Visibility != "public" {
+			p.Draft = true
+			data.Warnf("「%s」在原站仅会员可见，已导入为草稿", p.Title)
+		}
+		if !p.Draft {
+			p.Aliases = append(p.Aliases, "/"+gp.Slug+"/")
+		}
+		data.Posts = append(data.Posts, p)
+	}
+	if pages > 0 {
+		data.Warnf("跳过页面 %d 个", pages)
+	}
+	return
+}
+
+// ghostContent 优先使用 Markdown 原文，其次为 mobiledoc 中的 Markdown 卡片，最后使用 HTML
+func ghostContent(gp ghostPost) string {
+	if gp.Markdown != "" {
+		return gp.Markdown
+	}
+	if gp.Mobiledoc != "" {
+		var doc ghostMobiledoc
+		if json.Unmarshal([]byte(gp.Mobiledoc), &doc) == nil && len(doc.Cards) == 1 && len(doc.Cards[0]) == 2 {
+			var name string
+			var card struct {
+				Markdown string `json:"markdown"`
+			}
+			if json.Unmarshal(doc.Cards[0][0], &name) == nil && (name == "markdown" || name == "card-markdown") &&
+				json.Unmarshal(doc.Cards[0][1], &card) == nil && card.Markdown != "" {
+				return card.Markdown
+			}
+		}
+	}
+	return gp.HTML
+}
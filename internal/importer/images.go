@@ -0,0 +1,269 @@
+package importer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"ginana-blog/internal/config"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// 单张图片的大小上限
+const maxImageSize = 20 << 20
+
+var (
+	mdImage    = regexp.MustCompile(`(!\[[^\]]*\]\(\s*<?)([^)\s>]+)`)
+	htmlImage  = regexp.MustCompile(`(?i)(<img\b[^>]*?\bsrc\s*=\s*["'])([^"']+)`)
+	figureSrc  = regexp.MustCompile(`(\{\{<\s*figure\b[^>]*?\bsrc\s*=\s*["']?)([^"'\s>]+)`)
+	imageExts  = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".svg": true, ".bmp": true, ".ico": true}
+	httpClient = &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{Timeout: 10 * time.Second, Control: dialPublic}).DialContext,
+		},
+	}
+)
+
+// 导入内容中的图片地址不可信，不下载内网、本机与链路本地地址上的资源
+var blockedNets = parseCIDRs(
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+	"172.16.0.0/12", "192.168.0.0/16", "224.0.0.0/4", "240.0.0.0/4",
+	"::/128", "::1/128", "fc00::/7", "fe80::/10", "ff00::/8",
+)
+
+func parseCIDRs(list ...string) (nets []*net.IPNet) {
+	for _, s := range list {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return
+}
+
+// publicIP 是否为可访问的公网地址
+func publicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialPublic 在域名解析之后、建立连接之前检查目标地址，跳转与 DNS 重绑定同样受限
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicIP(net.ParseIP(host)) {
+		return fmt.Errorf("拒绝访问内网地址 %s", host)
+	}
+	return nil
+}
+
+// localizer 将文章引用的图片转存到 static/upload/import，并改写为站内地址
+type localizer struct {
+	src      *source
+	opt      *Options
+	site     *url.URL
+	prefix   string            // 静态文件URL前缀，如 /static
+	dir      string            // 静态文件目录，如 ../static
+	saveDir  string            // 本次导入的图片目录，相对于 dir
+	done     map[string]string // 原地址 -> 新地址
+	failed   map[string]bool
+	sequence int
+}
+
+func newLocalizer(src *source, opt *Options) *localizer {
+	l := &localizer{
+		src:     src,
+		opt:     opt,
+		saveDir: "upload/import/" + time.Now().Format("20060102"),
+		done:    make(map[string]string),
+		failed:  make(map[string]bool),
+	}
+	l.prefix, l.dir = config.StaticRoot(opt.StaticDir)
+	if opt.SiteURL != "" {
+		if u, err := url.Parse(opt.SiteURL); err == nil && u.Host != "" {
+			l.site = u
+		}
+	}
+	return l
+}
+
+func (l *localizer) run() {
+	for _, p := range l.src.data.Posts {
+		dirs := l.src.dirs[p]
+		rewrite := func(re *regexp.Regexp, s string) string {
+			return replaceGroup(re, s, func(u string) string {
+				return l.image(u, dirs)
+			})
+		}
+		p.Content = rewrite(mdImage, p.Content)
+		p.Content = rewrite(htmlImage, p.Content)
+		p.Content = rewrite(figureSrc, p.Content)
+		if p.Cover != "" {
+			p.Cover = l.image(p.Cover, dirs)
+		}
+	}
+	l.src.data.Images = len(l.done)
+}
+
+// image 返回图片的新地址，无法处理时保留原地址
+func (l *localizer) image(raw string, dirs []string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.HasPrefix(raw, "data:") || strings.HasPrefix(raw, l.prefix+"/") {
+		return raw
+	}
+	if v, ok := l.done[raw]; ok {
+		return v
+	}
+	if l.failed[raw] {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	var res string
+	switch {
+	case u.Scheme == "http" || u.Scheme == "https":
+		if l.site != nil && strings.EqualFold(u.Host, l.site.Host) {
+			res, err = l.local(u.Path, nil)
+		}
+		if res == "" {
+			res, err = l.remote(raw)
+		}
+	case u.Scheme != "" || u.Host != "":
+		return raw
+	default:
+		res, err = l.local(u.Path, dirs)
+		if res == "" && strings.HasPrefix(u.Path, "/") && l.site != nil {
+			res, err = l.remote(l.site.ResolveReference(u).String())
+		}
+	}
+	if res == "" {
+		l.failed[raw] = true
+		if err != nil {
+			l.src.data.Warnf("图片 %s 未转存：%v", raw, err)
+		} else {
+			l.src.data.Warnf("图片 %s 未找到，保留原地址", raw)
+		}
+		return raw
+	}
+	l.done[raw] = res
+	return res
+}
+
+// local 在文章目录与站点目录中查找图片文件
+func (l *localizer) local(p string, dirs []string) (res string, err error) {
+	p, _ = url.PathUnescape(p)
+	if !imageExts[strings.ToLower(path.Ext(p))] {
+		return
+	}
+	var candidates []string
+	if !strings.HasPrefix(p, "/") {
+		candidates = append(candidates, dirs...)
+	}
+	candidates = append(candidates, l.src.roots...)
+	for _, dir := range candidates {
+		file := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+p)))
+		if fi, e := os.Stat(file); e == nil && !fi.IsDir() {
+			if l.opt.DryRun {
+				return l.url(path.Ext(p)), nil
+			}
+			f, e := os.Open(file)
+			if e != nil {
+				return "", e
+			}
+			defer f.Close()
+			return l.save(f, path.Ext(p))
+		}
+	}
+	return
+}
+
+// remote 下载图片，试运行时不下载
+func (l *localizer) remote(raw string) (res string, err error) {
+	ext := strings.ToLower(path.Ext(strings.SplitN(raw, "?", 2)[0]))
+	if l.opt.DryRun {
+		if !imageExts[ext] {
+			ext = ".jpg"
+		}
+		return l.url(ext), nil
+	}
+	resp, err := httpClient.Get(raw)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	ct := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(ct, "image/") {
+		return "", fmt.Errorf("不是图片（%s）", ct)
+	}
+	if !imageExts[ext] {
+		ext = ".jpg"
+		if exts, _ := mime.ExtensionsByType(strings.SplitN(ct, ";", 2)[0]); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	return l.save(resp.Body, ext)
+}
+
+func (l *localizer) save(r io.Reader, ext string) (res string, err error) {
+	b, err := ioutil.ReadAll(io.LimitReader(r, maxImageSize+1))
+	if err != nil {
+		return
+	}
+	if len(b) > maxImageSize {
+		return "", fmt.Errorf("超过 %dMB", maxImageSize>>20)
+	}
+	sum := sha1.Sum(b)
+	name := hex.EncodeToString(sum[:8]) + strings.ToLower(ext)
+	dir := filepath.Join(l.dir, filepath.FromSlash(l.saveDir))
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+		return
+	}
+	return l.prefix + "/" + l.saveDir + "/" + name, nil
+}
+
+// url 试运行时的示意地址
+func (l *localizer) url(ext string) string {
+	l.sequence++
+	return fmt.Sprintf("%s/%s/%d%s", l.prefix, l.saveDir, l.sequence, strings.ToLower(ext))
+}
+
+// replaceGroup 替换正则第二个分组的内容
+func replaceGroup(re *regexp.Regexp, s string, fn func(string) string) string {
+	return re.ReplaceAllStringFunc(s, func(m string) string {
+		sub := re.FindStringSubmatch(m)
+		return sub[1] + fn(sub[2])
+	})
+}
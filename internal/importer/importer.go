@@ -0,0 +1,137 @@
+// Package importer 从其他博客系统的导出文件解析文章、标签、评论与用户，
+// 将引用的图片转存到 static/upload 后交由 service 写入数据库
+package importer
+
+import (
+	"archive/zip"
+	"fmt"
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/service"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Options struct {
+	Format    string // wxr、hexo、hugo、ghost
+	Path      string // 导出文件，或 Markdown 站点目录
+	StaticDir string // 同配置中的 StaticDir，图片转存到其下的 upload/import
+	SiteURL   string // 原站地址，本地找不到的站内图片从原站下载
+	DryRun    bool   // 只生成报告，不写数据库也不保存图片
+}
+
+// source 解析结果及图片查找所需的目录信息
+type source struct {
+	data  *model.ImportData
+	roots []string                       // 以 / 开头的图片路径的查找目录
+	dirs  map[*model.ImportPost][]string // 文章所在目录，用于相对路径图片
+}
+
+// Run 解析、转存图片并导入，试运行时返回预计结果
+func Run(svc service.Service, opt *Options) (report *model.ImportReport, err error) {
+	src, err := parse(opt.Format, opt.Path)
+	if err != nil {
+		return
+	}
+	newLocalizer(src, opt).run()
+	return svc.ImportContent(src.data, opt.DryRun)
+}
+
+// Parse 解析导出内容，不处理图片
+func Parse(format, path string) (data *model.ImportData, err error) {
+	src, err := parse(format, path)
+	if err != nil {
+		return
+	}
+	return src.data, nil
+}
+
+func parse(format, path string) (src *source, err error) {
+	switch format {
+	case model.ImportWXR:
+		if path, err = findFile(path, ".xml"); err != nil {
+			return
+		}
+		return parseWXR(path)
+	case model.ImportGhost:
+		if path, err = findFile(path, ".json"); err != nil {
+			return
+		}
+		return parseGhost(path)
+	case model.ImportHexo, model.ImportHugo:
+		return parseMarkdownSite(format, path)
+	}
+	return nil, fmt.Errorf("不支持的导入格式：%s", format)
+}
+
+// findFile 路径为目录（如解压后的上传文件）时查找其中第一个指定扩展名的文件
+func findFile(path, ext string) (file string, err error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if !fi.IsDir() {
+		return path, nil
+	}
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil || file != "" {
+			return err
+		}
+		if !info.IsDir() && strings.EqualFold(filepath.Ext(p), ext) {
+			file = p
+		}
+		return nil
+	})
+	if err == nil && file == "" {
+		err = fmt.Errorf("目录 %s 中没有 %s 文件", path, ext)
+	}
+	return
+}
+
+// Unzip 解压上传的压缩包，拒绝指向目标目录之外的条目
+func Unzip(file, dest string) (err error) {
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+	dest, err = filepath.Abs(dest)
+	if err != nil {
+		return
+	}
+	for _, f := range r.File {
+		name := filepath.Join(dest, filepath.FromSlash(f.Name))
+		if name != dest && !strings.HasPrefix(name, dest+string(os.PathSeparator)) {
+			return fmt.Errorf("压缩包中的路径不合法：%s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err = os.MkdirAll(name, 0755); err != nil {
+				return
+			}
+			continue
+		}
+		if err = unzipFile(f, name); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func unzipFile(f *zip.File, name string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+	w, err := os.Create(name)
+	if err != nil {
+		return
+	}
+	defer w.Close()
+	_, err = io.Copy(w, rc)
+	return
+}
@@ -0,0 +1,241 @@
+package importer
+
+import (
+	"ginana-blog/internal/model"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testWXR = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"
+     xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:wp="http://wordpress.org/export/1.2/"
+     xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/">
+<channel>
+  <title>Old Blog</title>
+  <wp:author><wp:author_id>1</wp:author_id><wp:author_login>admin</wp:author_login>
+    <wp:author_email>admin@example.com</wp:author_email><wp:author_display_name>管理员</wp:author_display_name></wp:author>
+  <wp:tag><wp:tag_slug>golang</wp:tag_slug><wp:tag_name>Go</wp:tag_name></wp:tag>
+  <wp:category><wp:category_nicename>uncategorized</wp:category_nicename><wp:cat_name>Uncategorized</wp:cat_name></wp:category>
+  <item>
+    <title>你好世界</title>
+    <link>https://old.example.com/2019/01/02/hello-world/</link>
+    <dc:creator>admin</dc:creator>
+    <content:encoded><![CDATA[[caption id="1"]<img src="/wp-content/uploads/a.png" />[/caption]正文]]></content:encoded>
+    <excerpt:encoded><![CDATA[摘要]]></excerpt:encoded>
+    <wp:post_id>10</wp:post_id>
+    <wp:post_date>2019-01-02 08:30:00</wp:post_date>
+    <wp:post_name>%e4%bd%a0%e5%a5%bd</wp:post_name>
+    <wp:status>publish</wp:status>
+    <wp:post_type>post</wp:post_type>
+    <wp:post_password></wp:post_password>
+    <category domain="post_tag" nicename="golang"><![CDATA[Go]]></category>
+    <category domain="category" nicename="uncategorized"><![CDATA[Uncategorized]]></category>
+    <wp:comment><wp:comment_id>5</wp:comment_id><wp:comment_author>路人</wp:comment_author>
+      <wp:comment_date>2019-01-03 10:00:00</wp:comment_date><wp:comment_content>不错</wp:comment_content>
+      <wp:comment_approved>1</wp:comment_approved><wp:comment_type>comment</wp:comment_type>
+      <wp:comment_parent>0</wp:comment_parent><wp:comment_user_id>0</wp:comment_user_id></wp:comment>
+    <wp:comment><wp:comment_id>6</wp:comment_id><wp:comment_author>admin</wp:comment_author>
+      <wp:comment_date>2019-01-03 11:00:00</wp:comment_date><wp:comment_content>谢谢</wp:comment_content>
+      <wp:comment_approved>1</wp:comment_approved><wp:comment_type></wp:comment_type>
+      <wp:comment_parent>5</wp:comment_parent><wp:comment_user_id>1</wp:comment_user_id></wp:comment>
+    <wp:comment><wp:comment_id>7</wp:comment_id><wp:comment_author>spam</wp:comment_author>
+      <wp:comment_approved>spam</wp:comment_approved></wp:comment>
+  </item>
+  <item>
+    <title>关于</title>
+    <wp:post_id>11</wp:post_id>
+    <wp:status>publish</wp:status>
+    <wp:post_type>page</wp:post_type>
+  </item>
+</channel>
+</rss>`
+
+const testGhost = `{"db":[{"meta":{"version":"4.0.0"},"data":{
+  "posts":[
+    {"id":"p1","title":"Ghost Post","slug":"ghost-post","mobiledoc":"{\"version\":\"0.3.1\",\"cards\":[[\"markdown\",{\"markdown\":\"![x](__GHOST_URL__/content/images/x.png)\"}]]}",
+     "feature_image":"__GHOST_URL__/content/images/cover.jpg","type":"post","status":"published","visibility":"public",
+     "created_at":"2020-05-01T10:00:00.000Z","published_at":"2020-05-02T10:00:00.000Z","updated_at":"2020-05-03T10:00:00.000Z"},
+    {"id":"p2","title":"About","slug":"about","html":"<p>about</p>","type":"page","status":"published"}
+  ],
+  "tags":[{"id":"t1","name":"News","slug":"news"},{"id":"t2","name":"#internal","slug":"hash-internal"}],
+  "posts_tags":[{"post_id":"p1","tag_id":"t1"},{"post_id":"p1","tag_id":"t2"}],
+  "users":[{"id":"u1","name":"Ghost Writer","slug":"writer","email":"writer@example.com"}],
+  "posts_authors":[{"post_id":"p1","author_id":"u1"}]
+}}]}`
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "importer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	Convey("WordPress WXR", t, func() {
+		path := filepath.Join(dir, "wxr", "export.xml")
+		writeFile(t, path, testWXR)
+		data, err := Parse(model.ImportWXR, filepath.Dir(path))
+		So(err, ShouldBeNil)
+		So(data.Users, ShouldHaveLength, 1)
+		So(data.Users[0].Nickname, ShouldEqual, "管理员")
+		So(data.Posts, ShouldHaveLength, 1)
+		p := data.Posts[0]
+		So(p.Title, ShouldEqual, "你好世界")
+		So(p.Slug, ShouldEqual, "你好")
+		So(p.CreatedAt.Format("2006-01-02 15:04"), ShouldEqual, "2019-01-02 08:30")
+		So(p.Content, ShouldEqual, `<img src="/wp-content/uploads/a.png" />正文`)
+		So(p.Tags, ShouldResemble, []string{"Go"})
+		So(p.Aliases, ShouldResemble, []string{"/2019/01/02/hello-world/"})
+		So(p.Comments, ShouldHaveLength, 2)
+		So(p.Comments[1].ParentKey, ShouldEqual, "5")
+		So(p.Comments[1].User, ShouldEqual, "admin")
+		So(data.Warnings, ShouldResemble, []string{"跳过类型或状态为 page 的条目 1 个"})
+	})
+
+	Convey("Ghost JSON", t, func() {
+		path := filepath.Join(dir, "ghost.json")
+		writeFile(t, path, testGhost)
+		data, err := Parse(model.ImportGhost, path)
+		So(err, ShouldBeNil)
+		So(data.Posts, ShouldHaveLength, 1)
+		p := data.Posts[0]
+		So(p.Content, ShouldEqual, "![x](/content/images/x.png)")
+		So(p.Cover, ShouldEqual, "/content/images/cover.jpg")
+		So(p.Author, ShouldEqual, "u1")
+		So(p.Tags, ShouldResemble, []string{"News"})
+		So(p.Draft, ShouldBeFalse)
+		So(p.CreatedAt.UTC().Format("2006-01-02"), ShouldEqual, "2020-05-02")
+		So(p.Aliases, ShouldResemble, []string{"/ghost-post/"})
+		So(data.Tags, ShouldHaveLength, 1)
+	})
+
+	Convey("Hexo 站点目录", t, func() {
+		root := filepath.Join(dir, "hexo")
+		writeFile(t, filepath.Join(root, "_config.yml"), "permalink: posts/:title.html\n")
+		writeFile(t, filepath.Join(root, "source", "_posts", "hello.md"),
+			"---\ntitle: Hello Hexo\ndate: 2018-03-04 05:06:07\ntags: [a, b]\ncategories:\n- [Tech, Go]\n---\n正文 {% asset_img pic.png 配图 %}\n")
+		writeFile(t, filepath.Join(root, "source", "_drafts", "todo.md"), "title: 草稿\n---\n未完成\n")
+		data, err := Parse(model.ImportHexo, root)
+		So(err, ShouldBeNil)
+		So(data.Posts, ShouldHaveLength, 2)
+		var post, draft *model.ImportPost
+		for _, p := range data.Posts {
+			if p.Draft {
+				draft = p
+			} else {
+				post = p
+			}
+		}
+		So(post.Title, ShouldEqual, "Hello Hexo")
+		So(post.Slug, ShouldEqual, "hello")
+		So(post.Content, ShouldEqual, "正文 ![配图](pic.png)")
+		So(post.Tags, ShouldResemble, []string{"a", "b", "Tech", "Go"})
+		So(post.CreatedAt.Format("2006-01-02 15:04:05"), ShouldEqual, "2018-03-04 05:06:07")
+		So(post.Aliases, ShouldResemble, []string{"/posts/hello.html"})
+		So(draft.Title, ShouldEqual, "草稿")
+		So(draft.Content, ShouldEqual, "未完成")
+		So(draft.Aliases, ShouldBeEmpty)
+	})
+
+	Convey("Hugo 站点目录", t, func() {
+		root := filepath.Join(dir, "hugo")
+		writeFile(t, filepath.Join(root, "content", "posts", "_index.md"), "+++\ntitle = \"Posts\"\n+++\n")
+		writeFile(t, filepath.Join(root, "content", "posts", "bundle", "index.md"),
+			"+++\ntitle = \"Bundle\"\ndate = 2021-06-07T08:00:00+08:00\ndraft = false\ntags = [\"hugo\"]\naliases = [\"/old/bundle/\"]\n+++\n![a](a.png)\n")
+		writeFile(t, filepath.Join(root, "content", "posts", "json.md"), "{\"title\": \"JSON\", \"slug\": \"json-fm\", \"draft\": true}\nbody\n")
+		data, err := Parse(model.ImportHugo, root)
+		So(err, ShouldBeNil)
+		So(data.Posts, ShouldHaveLength, 2)
+		for _, p := range data.Posts {
+			switch p.Title {
+			case "Bundle":
+				So(p.Slug, ShouldEqual, "bundle")
+				So(p.Aliases, ShouldResemble, []string{"/posts/bundle/", "/old/bundle/"})
+				So(p.CreatedAt.UTC().Format("2006-01-02 15:04"), ShouldEqual, "2021-06-07 00:00")
+			case "JSON":
+				So(p.Slug, ShouldEqual, "json-fm")
+				So(p.Draft, ShouldBeTrue)
+				So(strings.TrimSpace(p.Content), ShouldEqual, "body")
+			default:
+				t.Errorf("unexpected post %s", p.Title)
+			}
+		}
+	})
+}
+
+func TestLocalizer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "importer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "hugo")
+	writeFile(t, filepath.Join(root, "content", "posts", "p", "index.md"),
+		"---\ntitle: P\n---\n![a](a.png) <img src=\"/img/b.png\"> ![c](missing.png) ![d](/static/upload/d.png)\n")
+	writeFile(t, filepath.Join(root, "content", "posts", "p", "a.png"), "a")
+	writeFile(t, filepath.Join(root, "static", "img", "b.png"), "b")
+	static := filepath.Join(dir, "static")
+
+	Convey("转存图片并改写地址", t, func() {
+		src, err := parse(model.ImportHugo, root)
+		So(err, ShouldBeNil)
+		opt := &Options{StaticDir: "static:" + static}
+		newLocalizer(src, opt).run()
+		p := src.data.Posts[0]
+		So(src.data.Images, ShouldEqual, 2)
+		So(p.Content, ShouldContainSubstring, "![c](missing.png)")
+		So(p.Content, ShouldContainSubstring, "![d](/static/upload/d.png)")
+		So(p.Content, ShouldNotContainSubstring, "](a.png)")
+		So(p.Content, ShouldNotContainSubstring, `src="/img/b.png"`)
+		So(src.data.Warnings, ShouldHaveLength, 1)
+		files, _ := filepath.Glob(filepath.Join(static, "upload", "import", "*", "*.png"))
+		So(files, ShouldHaveLength, 2)
+	})
+
+	Convey("试运行不保存图片", t, func() {
+		src, err := parse(model.ImportHugo, root)
+		So(err, ShouldBeNil)
+		static2 := filepath.Join(dir, "static2")
+		newLocalizer(src, &Options{StaticDir: "static:" + static2, DryRun: true}).run()
+		So(src.data.Images, ShouldEqual, 2)
+		_, err = os.Stat(static2)
+		So(os.IsNotExist(err), ShouldBeTrue)
+	})
+}
+
+func TestPublicIP(t *testing.T) {
+	Convey("publicIP", t, func() {
+		for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.20.0.1", "192.168.1.1", "169.254.169.254",
+			"0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1"} {
+			So(publicIP(net.ParseIP(ip)), ShouldBeFalse)
+		}
+		So(publicIP(net.ParseIP("93.184.216.34")), ShouldBeTrue)
+		So(publicIP(net.ParseIP("2606:2800:220:1::1")), ShouldBeTrue)
+		So(publicIP(nil), ShouldBeFalse)
+	})
+	Convey("不下载内网地址的图片", t, func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png"))
+		}))
+		defer srv.Close()
+		_, err := httpClient.Get(srv.URL + "/a.png")
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "内网地址")
+	})
+}
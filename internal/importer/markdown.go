@@ -0,0 +1,345 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"ginana-blog/internal/model"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Hexo 未配置 permalink 时的默认值
+const hexoPermalink = ":year/:month/:day/:title/"
+
+// {% asset_img name [title] %} 转换为 Markdown 图片
+var hexoAssetImg = regexp.MustCompile(`\{%\s*asset_img\s+(\S+)\s*(.*?)\s*%\}`)
+
+// markdownSite Hexo 或 Hugo 站点目录
+type markdownSite struct {
+	format    string
+	root      string
+	permalink string // Hexo 的链接格式
+	src       *source
+}
+
+type frontMatter map[string]interface{}
+
+func parseMarkdownSite(format, root string) (src *source, err error) {
+	fi, err := os.Stat(root)
+	if err != nil {
+		return
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s 格式需要指定站点或文章目录", format)
+	}
+	data := &model.ImportData{Format: format}
+	site := &markdownSite{
+		format: format,
+		root:   root,
+		src:    &source{data: data, dirs: make(map[*model.ImportPost][]string)},
+	}
+	// 文章目录及是否为草稿
+	dirs := map[string]bool{root: false}
+	switch format {
+	case model.ImportHexo:
+		if isDir(filepath.Join(root, "source", "_posts")) {
+			dirs = map[string]bool{
+				filepath.Join(root, "source", "_posts"):  false,
+				filepath.Join(root, "source", "_drafts"): true,
+			}
+			site.src.roots = append(site.src.roots, filepath.Join(root, "source"))
+		}
+		site.permalink = hexoConfigPermalink(root)
+	case model.ImportHugo:
+		if isDir(filepath.Join(root, "content")) {
+			dirs = map[string]bool{filepath.Join(root, "content"): false}
+			site.src.roots = append(site.src.roots, filepath.Join(root, "static"))
+		}
+	}
+	site.src.roots = append(site.src.roots, root)
+	for dir, draft := range dirs {
+		if !isDir(dir) {
+			continue
+		}
+		if err = site.walk(dir, draft); err != nil {
+			return
+		}
+	}
+	return site.src, nil
+}
+
+func (site *markdownSite) walk(dir string, draft bool) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".md" && ext != ".markdown" || name == "_index.md" {
+			return nil
+		}
+		if e := site.parseFile(dir, path, draft); e != nil {
+			site.src.data.Warnf("%s：%v", site.rel(path), e)
+		}
+		return nil
+	})
+}
+
+func (site *markdownSite) parseFile(dir, path string, draft bool) (err error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	fm, body, err := splitFrontMatter(b, site.format == model.ImportHexo)
+	if err != nil {
+		return
+	}
+	if fm.bool("draft") || fm.has("published") && !fm.bool("published") {
+		draft = true
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if name == "index" {
+		name = filepath.Base(filepath.Dir(path))
+	}
+	p := &model.ImportPost{
+		Key:       site.rel(path),
+		Source:    site.rel(path),
+		Title:     fm.string("title"),
+		Slug:      fm.string("slug"),
+		Content:   strings.TrimSpace(hexoAssetImg.ReplaceAllString(body, "![$2]($1)")),
+		Cover:     fm.first("cover", "thumbnail", "image", "featured_image", "banner"),
+		Author:    fm.string("author"),
+		Draft:     draft,
+		CreatedAt: fm.time("date"),
+		UpdatedAt: fm.time("updated", "lastmod"),
+	}
+	if p.Title == "" {
+		p.Title = name
+	}
+	if p.CreatedAt.IsZero() {
+		if fi, e := os.Stat(path); e == nil {
+			p.CreatedAt = fi.ModTime()
+		}
+	}
+	link := fm.string("url")
+	if site.format == model.ImportHexo {
+		link = fm.string("permalink")
+	}
+	if p.Slug == "" && link != "" {
+		p.Slug = strings.Trim(strings.TrimSuffix(link, ".html"), "/")
+	}
+	if p.Slug == "" {
+		p.Slug = name
+	}
+	p.Tags = append(fm.strings("tags"), fm.strings("categories")...)
+	if p.Author != "" {
+		site.src.data.Users = appendUser(site.src.data.Users, p.Author)
+	}
+	if !p.Draft {
+		if link == "" {
+			link = site.defaultLink(dir, path, p)
+		}
+		p.Aliases = append(p.Aliases, "/"+strings.TrimPrefix(link, "/"))
+		for _, alias := range fm.strings("aliases") {
+			p.Aliases = append(p.Aliases, "/"+strings.TrimPrefix(alias, "/"))
+		}
+	}
+	// 文章所在目录，Hexo 的资源文件夹与文章同名
+	site.src.dirs[p] = []string{filepath.Dir(path), strings.TrimSuffix(path, filepath.Ext(path))}
+	site.src.data.Posts = append(site.src.data.Posts, p)
+	return
+}
+
+// defaultLink 按原站的链接规则推算文章原链接
+func (site *markdownSite) defaultLink(dir, path string, p *model.ImportPost) string {
+	rel := filepath.ToSlash(site.relTo(dir, path))
+	title := strings.TrimSuffix(rel, filepath.Ext(rel))
+	if site.format == model.ImportHugo {
+		section := ""
+		if i := strings.Index(title, "/"); i > 0 {
+			section = title[:i] + "/"
+		}
+		return "/" + section + p.Slug + "/"
+	}
+	t := p.CreatedAt
+	return strings.NewReplacer(
+		":year", t.Format("2006"),
+		":month", t.Format("01"),
+		":i_month", t.Format("1"),
+		":day", t.Format("02"),
+		":i_day", t.Format("2"),
+		":hour", t.Format("15"),
+		":minute", t.Format("04"),
+		":second", t.Format("05"),
+		":title", title,
+		":name", filepath.Base(title),
+		":post_title", p.Slug,
+	).Replace(site.permalink)
+}
+
+func (site *markdownSite) rel(path string) string {
+	return filepath.ToSlash(site.relTo(site.root, path))
+}
+
+func (site *markdownSite) relTo(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// hexoConfigPermalink 读取 _config.yml 中的 permalink
+func hexoConfigPermalink(root string) string {
+	var cfg struct {
+		Permalink string `yaml:"permalink"`
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(root, "_config.yml")); err == nil {
+		_ = yaml.Unmarshal(b, &cfg)
+	}
+	if cfg.Permalink == "" {
+		return hexoPermalink
+	}
+	return cfg.Permalink
+}
+
+func appendUser(users []*model.ImportUser, name string) []*model.ImportUser {
+	for _, u := range users {
+		if u.Key == name {
+			return users
+		}
+	}
+	return append(users, &model.ImportUser{Key: name, Username: name, Nickname: name})
+}
+
+// splitFrontMatter 拆分 YAML（---）、TOML（+++）或 JSON 格式的 front matter 与正文
+// Hexo 允许省略开头的 ---
+func splitFrontMatter(b []byte, bare bool) (fm frontMatter, body string, err error) {
+	fm = make(frontMatter)
+	s := strings.TrimPrefix(strings.Replace(string(b), "\r\n", "\n", -1), "\ufeff")
+	switch {
+	case strings.HasPrefix(s, "---\n"):
+		head, rest := cutFence(s[4:], "---")
+		return fm, rest, yaml.Unmarshal([]byte(head), &fm)
+	case strings.HasPrefix(s, "+++\n"):
+		head, rest := cutFence(s[4:], "+++")
+		_, err = toml.Decode(head, &fm)
+		return fm, rest, err
+	case strings.HasPrefix(s, "{"):
+		r := strings.NewReader(s)
+		dec := json.NewDecoder(r)
+		if err = dec.Decode(&fm); err != nil {
+			return
+		}
+		rest, _ := ioutil.ReadAll(io.MultiReader(dec.Buffered(), r))
+		return fm, string(rest), nil
+	case bare:
+		head, rest := cutFence(s, "---")
+		if head != s && yaml.Unmarshal([]byte(head), &fm) == nil && fm.has("title") {
+			return fm, rest, nil
+		}
+		fm = make(frontMatter)
+	}
+	return fm, s, nil
+}
+
+// cutFence 在单独成行的分隔符处切分
+func cutFence(s, fence string) (head, rest string) {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == fence {
+			return strings.Join(lines[:i], ""), strings.Join(lines[i+1:], "")
+		}
+	}
+	return s, ""
+}
+
+func (fm frontMatter) has(key string) bool {
+	_, ok := fm[key]
+	return ok
+}
+
+func (fm frontMatter) string(key string) string {
+	switch v := fm[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+func (fm frontMatter) first(keys ...string) string {
+	for _, key := range keys {
+		if v := fm.string(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (fm frontMatter) bool(key string) bool {
+	switch v := fm[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true" || v == "yes"
+	}
+	return false
+}
+
+func (fm frontMatter) time(keys ...string) time.Time {
+	for _, key := range keys {
+		if t, ok := fm[key].(time.Time); ok {
+			return t
+		}
+		if t := parseTime(fm.string(key)); !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// strings 列表或逗号分隔的字符串，Hexo 的多级分类会展开
+func (fm frontMatter) strings(key string) (list []string) {
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case string:
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					list = append(list, s)
+				}
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case nil:
+		default:
+			walk(fmt.Sprint(v))
+		}
+	}
+	walk(fm[key])
+	return
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
@@ -0,0 +1,222 @@
+package importer
+
+import (
+	"encoding/xml"
+	"ginana-blog/internal/model"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// WordPress 导出格式，wp 命名空间随版本变化，按本地名称匹配
+type wxrChannel struct {
+	Authors    []wxrAuthor   `xml:"channel>author"`
+	Tags       []wxrTag      `xml:"channel>tag"`
+	Categories []wxrCategory `xml:"channel>category"`
+	Items      []wxrItem     `xml:"channel>item"`
+}
+
+type wxrAuthor struct {
+	ID          string `xml:"author_id"`
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+type wxrTag struct {
+	Slug        string `xml:"tag_slug"`
+	Name        string `xml:"tag_name"`
+	Description string `xml:"tag_description"`
+}
+
+type wxrCategory struct {
+	Slug        string `xml:"category_nicename"`
+	Name        string `xml:"cat_name"`
+	Description string `xml:"category_description"`
+}
+
+type wxrItem struct {
+	Title      string        `xml:"title"`
+	Link       string        `xml:"link"`
+	Creator    string        `xml:"creator"`
+	Content    string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	ID         string        `xml:"post_id"`
+	Date       string        `xml:"post_date"`
+	Modified   string        `xml:"post_modified"`
+	Name       string        `xml:"post_name"`
+	Status     string        `xml:"status"`
+	Type       string        `xml:"post_type"`
+	Password   string        `xml:"post_password"`
+	Attachment string        `xml:"attachment_url"`
+	Categories []wxrItemTerm `xml:"category"`
+	Meta       []wxrMeta     `xml:"postmeta"`
+	Comments   []wxrComment  `xml:"comment"`
+}
+
+type wxrItemTerm struct {
+	Domain string `xml:"domain,attr"`
+	Name   string `xml:",chardata"`
+}
+
+type wxrMeta struct {
+	Key   string `xml:"meta_key"`
+	Value string `xml:"meta_value"`
+}
+
+type wxrComment struct {
+	ID       string `xml:"comment_id"`
+	Author   string `xml:"comment_author"`
+	Email    string `xml:"comment_author_email"`
+	IP       string `xml:"comment_author_IP"`
+	Date     string `xml:"comment_date"`
+	Content  string `xml:"comment_content"`
+	Approved string `xml:"comment_approved"`
+	Type     string `xml:"comment_type"`
+	Parent   string `xml:"comment_parent"`
+	UserID   string `xml:"comment_user_id"`
+}
+
+// [caption] 短代码只保留其中的图片与说明文字
+var wxrCaption = regexp.MustCompile(`\[/?caption[^\]]*\]`)
+
+// 默认分类不作为标签导入
+var wxrDefaultCategories = map[string]bool{"uncategorized": true, "未分类": true}
+
+func parseWXR(path string) (src *source, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	var ch wxrChannel
+	dec := xml.NewDecoder(f)
+	dec.Strict = false
+	if err = dec.Decode(&ch); err != nil {
+		return
+	}
+	data := &model.ImportData{Format: model.ImportWXR}
+	src = &source{data: data, roots: []string{filepath.Dir(path)}, dirs: make(map[*model.ImportPost][]string)}
+	logins := make(map[string]string)
+	for _, a := range ch.Authors {
+		logins[a.ID] = a.Login
+		data.Users = append(data.Users, &model.ImportUser{
+			Key: a.Login, Username: a.Login, Nickname: a.DisplayName, Email: a.Email,
+		})
+	}
+	for _, t := range ch.Tags {
+		data.Tags = append(data.Tags, &model.ImportTag{Name: t.Name, Slug: t.Slug, Description: t.Description})
+	}
+	for _, c := range ch.Categories {
+		if !wxrDefaultCategories[strings.ToLower(c.Name)] {
+			data.Tags = append(data.Tags, &model.ImportTag{Name: c.Name, Slug: c.Slug, Description: c.Description})
+		}
+	}
+	attachments := make(map[string]string)
+	for _, item := range ch.Items {
+		if item.Type == "attachment" {
+			attachments[item.ID] = item.Attachment
+		}
+	}
+	skipped := make(map[string]int)
+	for _, item := range ch.Items {
+		if item.Type != "post" {
+			skipped[item.Type]++
+			continue
+		}
+		p, ok := wxrPost(data, item, logins, attachments)
+		if !ok {
+			skipped[item.Status]++
+			continue
+		}
+		data.Posts = append(data.Posts, p)
+	}
+	for kind, n := range skipped {
+		if kind != "attachment" && kind != "nav_menu_item" {
+			data.Warnf("跳过类型或状态为 %s 的条目 %d 个", kind, n)
+		}
+	}
+	return
+}
+
+func wxrPost(data *model.ImportData, item wxrItem, logins, attachments map[string]string) (p *model.ImportPost, ok bool) {
+	p = &model.ImportPost{
+		Key:       item.ID,
+		Source:    "post " + item.ID,
+		Title:     strings.TrimSpace(item.Title),
+		Content:   wxrCaption.ReplaceAllString(item.Content, ""),
+		Author:    item.Creator,
+		CreatedAt: parseTime(item.Date),
+		UpdatedAt: parseTime(item.Modified),
+	}
+	p.Slug, _ = url.PathUnescape(item.Name)
+	switch item.Status {
+	case "publish", "future":
+		if u, err := url.Parse(item.Link); err == nil && u.RawQuery == "" {
+			p.Aliases = append(p.Aliases, u.Path)
+		}
+	case "draft", "pending", "private":
+		p.Draft = true
+	default:
+		return nil, false
+	}
+	if item.Password != "" {
+		p.Draft = true
+		data.Warnf("「%s」在原站设有访问密码，已导入为草稿", p.Title)
+	}
+	for _, c := range item.Categories {
+		if c.Domain == "post_tag" || c.Domain == "category" && !wxrDefaultCategories[strings.ToLower(c.Name)] {
+			p.Tags = append(p.Tags, c.Name)
+		}
+	}
+	for _, m := range item.Meta {
+		if m.Key == "_thumbnail_id" {
+			p.Cover = attachments[m.Value]
+		}
+	}
+	for _, c := range item.Comments {
+		if c.Approved != "1" || c.Type != "" && c.Type != "comment" {
+			continue
+		}
+		comment := &model.ImportComment{
+			Key:       c.ID,
+			User:      logins[c.UserID],
+			Author:    c.Author,
+			Email:     c.Email,
+			IP:        c.IP,
+			Content:   c.Content,
+			CreatedAt: parseTime(c.Date),
+		}
+		if c.Parent != "" && c.Parent != "0" {
+			comment.ParentKey = c.Parent
+		}
+		p.Comments = append(p.Comments, comment)
+	}
+	return p, true
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+}
+
+// parseTime 解析各平台导出的时间，无时区信息时按本地时间处理
+func parseTime(s string) (t time.Time) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasPrefix(s, "0000-00-00") {
+		return
+	}
+	for _, layout := range timeLayouts {
+		if v, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return v
+		}
+	}
+	return
+}
@@ -0,0 +1,258 @@
+// Package metrics 以 Prometheus 文本格式输出运行指标，只实现本项目用到的计数器、直方图与
+// 即时取值的仪表，指标在包内统一定义，各处直接引用
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType Prometheus 文本格式
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets 默认的耗时分桶，单位秒
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	HTTPRequestDuration = NewHistogramVec("ginana_http_request_duration_seconds",
+		"HTTP请求耗时，按路由统计", DefBuckets, "method", "route", "status")
+	TemplateRenderDuration = NewHistogramVec("ginana_template_render_duration_seconds",
+		"模板渲染耗时", DefBuckets, "template")
+	DBQueryDuration = NewHistogramVec("ginana_db_query_duration_seconds",
+		"数据库操作耗时", DefBuckets, "operation", "table")
+	CacheRequests = NewCounterVec("ginana_cache_requests_total",
+		"缓存读取次数，result 为 hit、miss 或 error", "key", "result")
+	Comments = NewCounterVec("ginana_comments_total",
+		"发表评论次数，result 为 created 或 failed", "result")
+	Logins = NewCounterVec("ginana_logins_total",
+		"登录次数，result 为 success 或 failure", "result")
+)
+
+var startTime = time.Now()
+
+func init() {
+	NewGaugeFunc("go_goroutines", "当前的 goroutine 数量", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	NewGaugeFunc("process_start_time_seconds", "进程启动时间", func() float64 {
+		return float64(startTime.Unix())
+	})
+}
+
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+var (
+	mu         sync.Mutex
+	collectors []collector
+)
+
+func register(c collector) {
+	mu.Lock()
+	collectors = append(collectors, c)
+	mu.Unlock()
+}
+
+// Write 输出全部指标
+func Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	mu.Lock()
+	list := append([]collector{}, collectors...)
+	mu.Unlock()
+	for _, c := range list {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	name, help string
+	labels     []string
+	mu         sync.Mutex
+	series     map[string]*counter
+}
+
+type counter struct {
+	values []string
+	value  float64
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, series: make(map[string]*counter)}
+	register(c)
+	return c
+}
+
+// Inc 计数加一，values 与定义时的标签一一对应
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *CounterVec) Add(v float64, values ...string) {
+	key := strings.Join(values, "\xff")
+	c.mu.Lock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counter{values: values}
+		c.series[key] = s
+	}
+	s.value += v
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	header(w, c.name, c.help, "counter")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, labelText(c.labels, s.values, "", ""), number(s.value))
+	}
+}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+	mu         sync.Mutex
+	series     map[string]*histogram
+}
+
+type histogram struct {
+	values []string
+	counts []uint64 // 每个分桶的累计数量
+	sum    float64
+	count  uint64
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogram)}
+	register(h)
+	return h
+}
+
+// Observe 记录一次取值
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := strings.Join(values, "\xff")
+	h.mu.Lock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{values: values, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+	h.mu.Unlock()
+}
+
+// Since 记录从 start 至今的耗时
+func (h *HistogramVec) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	header(w, h.name, h.help, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelText(h.labels, s.values, "le", number(upper)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelText(h.labels, s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labelText(h.labels, s.values, "", ""), number(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labelText(h.labels, s.values, "", ""), s.count)
+	}
+}
+
+// GaugeFunc 输出时取值的仪表
+type GaugeFunc struct {
+	name, help string
+	fn         func() float64
+}
+
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, fn: fn}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	header(w, g.name, g.help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.name, number(g.fn()))
+}
+
+func header(w *bufio.Writer, name, help, typ string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelText 拼接标签，extraName 非空时追加一个标签，如直方图的 le
+func labelText(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		v := ""
+		if i < len(values) {
+			v = values[i]
+		}
+		fmt.Fprintf(&b, `%s="%s"`, name, labelEscaper.Replace(v))
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, extraName, extraValue)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func number(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m interface{}) (keys []string) {
+	switch m := m.(type) {
+	case map[string]*counter:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]*histogram:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return
+}
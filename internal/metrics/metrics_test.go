@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	Convey("Write", t, func() {
+		c := NewCounterVec("test_total", "计数", "key", "result")
+		c.Inc("user", "hit")
+		c.Inc("user", "hit")
+		c.Inc(`a"b`, "miss")
+		h := NewHistogramVec("test_seconds", "耗时", []float64{0.1, 1}, "route")
+		h.Observe(0.05, "/a")
+		h.Observe(0.5, "/a")
+		h.Observe(5, "/a")
+
+		var buf bytes.Buffer
+		So(Write(&buf), ShouldBeNil)
+		out := buf.String()
+		So(out, ShouldContainSubstring, "# HELP test_total 计数\n# TYPE test_total counter\n"+
+			`test_total{key="a\"b",result="miss"} 1`+"\n"+
+			`test_total{key="user",result="hit"} 2`+"\n")
+		So(out, ShouldContainSubstring, "# TYPE test_seconds histogram\n"+
+			`test_seconds_bucket{route="/a",le="0.1"} 1`+"\n"+
+			`test_seconds_bucket{route="/a",le="1"} 2`+"\n"+
+			`test_seconds_bucket{route="/a",le="+Inf"} 3`+"\n"+
+			`test_seconds_sum{route="/a"} 5.55`+"\n"+
+			`test_seconds_count{route="/a"} 3`+"\n")
+		So(out, ShouldContainSubstring, "# TYPE go_goroutines gauge\ngo_goroutines ")
+	})
+}
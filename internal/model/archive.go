@@ -0,0 +1,112 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// ArchiveDay 某日发布的文章数，Date 格式为 2006-01-02
+type ArchiveDay struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+type ArchiveMonth struct {
+	Year  int   `json:"year"`
+	Month int   `json:"month"`
+	Count int64 `json:"count"`
+}
+
+type ArchiveYear struct {
+	Year   int             `json:"year"`
+	Count  int64           `json:"count"`
+	Months []*ArchiveMonth `json:"months"`
+}
+
+// CalendarDay 日历中的一天，Day 为0表示补位空格
+type CalendarDay struct {
+	Day   int   `json:"day"`
+	Count int64 `json:"count"`
+	Today bool  `json:"today"`
+}
+
+type Calendar struct {
+	Year  int              `json:"year"`
+	Month int              `json:"month"`
+	Weeks [][]*CalendarDay `json:"weeks"`
+	Prev  *ArchiveMonth    `json:"prev"`
+	Next  *ArchiveMonth    `json:"next"`
+}
+
+func (m *ArchiveMonth) Link() string {
+	return fmt.Sprintf("/archives/%d/%02d", m.Year, m.Month)
+}
+
+// Range 当月的起止时间 [start, end)
+func (m *ArchiveMonth) Range() (start, end time.Time) {
+	start = time.Date(m.Year, time.Month(m.Month), 1, 0, 0, 0, 0, time.Local)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// Link 当日归档页地址，补位空格返回空
+func (c *Calendar) Link(day int) string {
+	if day == 0 {
+		return ""
+	}
+	return fmt.Sprintf("/archives/%d/%02d/%02d", c.Year, c.Month, day)
+}
+
+// NewArchiveYears 按年、月汇总每日文章数，均按时间倒序
+func NewArchiveYears(days []*ArchiveDay) (years []*ArchiveYear) {
+	var year *ArchiveYear
+	var month *ArchiveMonth
+	for _, d := range days {
+		t, err := time.ParseInLocation("2006-01-02", d.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		if year == nil || year.Year != t.Year() {
+			year = &ArchiveYear{Year: t.Year()}
+			years = append(years, year)
+			month = nil
+		}
+		if month == nil || month.Month != int(t.Month()) {
+			month = &ArchiveMonth{Year: t.Year(), Month: int(t.Month())}
+			year.Months = append(year.Months, month)
+		}
+		year.Count += d.Count
+		month.Count += d.Count
+	}
+	return
+}
+
+// NewCalendar 生成月历，每周从周一开始，counts 为当月每日文章数
+func NewCalendar(year, month int, counts map[int]int64, now time.Time) *Calendar {
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	c := &Calendar{Year: first.Year(), Month: int(first.Month())}
+	prev, next := first.AddDate(0, -1, 0), first.AddDate(0, 1, 0)
+	c.Prev = &ArchiveMonth{Year: prev.Year(), Month: int(prev.Month())}
+	c.Next = &ArchiveMonth{Year: next.Year(), Month: int(next.Month())}
+	week := make([]*CalendarDay, 0, 7)
+	for i := 0; i < (int(first.Weekday())+6)%7; i++ {
+		week = append(week, &CalendarDay{})
+	}
+	for d := first; d.Before(next); d = d.AddDate(0, 0, 1) {
+		week = append(week, &CalendarDay{
+			Day:   d.Day(),
+			Count: counts[d.Day()],
+			Today: d.Year() == now.Year() && d.YearDay() == now.YearDay(),
+		})
+		if len(week) == 7 {
+			c.Weeks = append(c.Weeks, week)
+			week = make([]*CalendarDay, 0, 7)
+		}
+	}
+	if len(week) > 0 {
+		for len(week) < 7 {
+			week = append(week, &CalendarDay{})
+		}
+		c.Weeks = append(c.Weeks, week)
+	}
+	return c
+}
@@ -2,55 +2,89 @@ package model
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"ginana-blog/internal/render"
+	"html"
 	"regexp"
 	"strings"
 	"time"
 )
 
 type Article struct {
-	ID        int64     `json:"id" gorm:"primary_key;comment:'文章ID'"`
-	CreatedAt time.Time `json:"created_at" gorm:"comment:'创建时间'"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"comment:'更新时间'"`
-	Title     string    `json:"title" gorm:"type:VARCHAR(100);unique;not null;index;comment:'文章标题'"`
-	Color     string    `json:"color" gorm:"type:VARCHAR(10);not null;comment:'标题颜色'"`
-	Urlname   string    `json:"urlname" gorm:"type:VARCHAR(100);not null;comment:'特殊链接名称'"`
-	Urltype   int8      `json:"urltype" gorm:"comment:'特殊链接类型'"`
-	Content   string    `json:"content" gorm:"type:LONGTEXT;not null;comment:'文章内容'"`
-	Views     int64     `json:"views" gorm:"comment:'查看次数'"`
-	Status    int8      `json:"status" gorm:"index;comment:'文章状态'"` // 0-已发布,1-草稿箱,2-回收站
-	Istop     int8      `json:"istop" gorm:"index;comment:'置顶相关'"`
-	Cover     string    `json:"cover" gorm:"type:VARCHAR(255);default:'/static/upload/default/blog-default-0.png';not null;comment:'文章封面'"`
-	UserID    int64     `json:"user_id" gorm:"comment:'关联用户ID'"`
-	ComeFrom  string    `json:"come_from" gorm:"type:VARCHAR(300);not null;comment:'转载原文链接'"`
-	User      *User     `json:"user" gorm:"ForeignKey:UserID"`
-	Tags      []*Tag    `json:"tags" gorm:"many2many:article_tags"`
-	Prev      *Article  `json:"prev" gorm:"-"`
-	Next      *Article  `json:"next" gorm:"-"`
+	ID         int64             `json:"id" gorm:"primary_key;comment:'文章ID'"`
+	CreatedAt  time.Time         `json:"created_at" gorm:"comment:'创建时间'"`
+	UpdatedAt  time.Time         `json:"updated_at" gorm:"comment:'更新时间'"`
+	Title      string            `json:"title" gorm:"type:VARCHAR(100);unique;not null;index;comment:'文章标题'"`
+	Color      string            `json:"color" gorm:"type:VARCHAR(10);not null;comment:'标题颜色'"`
+	Urlname    string            `json:"urlname" gorm:"type:VARCHAR(100);not null;comment:'特殊链接名称'"`
+	Urltype    int8              `json:"urltype" gorm:"comment:'特殊链接类型'"`
+	Content    string            `json:"content" gorm:"type:LONGTEXT;not null;comment:'文章内容'"`
+	Views      int64             `json:"views" gorm:"comment:'查看次数'"`
+	Likes      int64             `json:"likes" gorm:"comment:'点赞与表情回应数'"`
+	Words      int64             `json:"word_count" gorm:"comment:'字数'"`
+	ReadTime   int64             `json:"reading_time" gorm:"comment:'阅读时长(分钟)'"`
+	Outline    string            `json:"-" gorm:"type:TEXT;comment:'标题大纲'"`
+	Status     int8              `json:"status" gorm:"index;comment:'文章状态'"` // 0-已发布,1-草稿箱,2-回收站
+	Istop      int8              `json:"istop" gorm:"index;comment:'置顶相关'"`
+	Cover      string            `json:"cover" gorm:"type:VARCHAR(255);default:'/static/upload/default/blog-default-0.png';not null;comment:'文章封面'"`
+	UserID     int64             `json:"user_id" gorm:"comment:'关联用户ID'"`
+	CategoryID int64             `json:"category_id" gorm:"index;not null;comment:'主分类ID'"`
+	ComeFrom   string            `json:"come_from" gorm:"type:VARCHAR(300);not null;comment:'转载原文链接'"`
+	Visibility int8              `json:"visibility" gorm:"index;not null;default:0;comment:'可见性'"` // 0-公开,1-密码,2-登录用户,3-指定角色
+	Password   string            `json:"-" gorm:"type:VARCHAR(100);not null;comment:'访问密码'"`
+	RoleIDs    string            `json:"-" gorm:"type:VARCHAR(255);not null;comment:'可见角色ID'"`
+	Locked     bool              `json:"locked" gorm:"-"` // 当前访客无权查看全文
+	User       *User             `json:"user" gorm:"ForeignKey:UserID"`
+	Tags       []*Tag            `json:"tags" gorm:"many2many:article_tags"`
+	Prev       *Article          `json:"prev" gorm:"-"`
+	Next       *Article          `json:"next" gorm:"-"`
+	HTML       string            `json:"html" gorm:"-"` // 服务端渲染并过滤后的正文
+	TOC        string            `json:"toc" gorm:"-"`  // 目录
+	PlainText  string            `json:"-" gorm:"-"`    // 纯文本，用于摘要
+	Headings   []*render.Heading `json:"outline" gorm:"-"`
+	Reactions  []*ReactionCount  `json:"reactions" gorm:"-"`
+	Series     *SeriesNav        `json:"series" gorm:"-"`
+	Category   *Category         `json:"category" gorm:"-"`
 }
 
+const (
+	VisibilityPublic   = 0 // 公开
+	VisibilityPassword = 1 // 凭密码访问
+	VisibilityMember   = 2 // 登录用户可见
+	VisibilityRole     = 3 // 指定角色可见
+)
+
 type ArticleQueryParam struct {
-	Order   string
-	TagID   int64
-	Status  int
-	Search  string
-	Keyword string
+	Order       string
+	Public      bool // 仅公开文章
+	TagID       int64
+	CategoryIDs []int64   // 分类及其子分类
+	StartAt     time.Time // 发布时间起，包含
+	EndAt       time.Time // 发布时间止，不包含
+	Status      int
+	Search      string
+	Keyword     string
 }
 
 type ArticleReq struct {
-	ID                 int64  `form:"id" valid:"omitempty,gte=0"`
-	Title              string `form:"title" valid:"required,max=100"`
-	Color              string `form:"color" valid:"omitempty,iscolor"`
-	Urlname            string `form:"urlname" valid:"omitempty"`
-	Urltype            int8   `form:"urltype" valid:"omitempty,numeric"`
-	ContentMarkdownDoc string `form:"content-markdown-doc" valid:"required"`
-	ContentHtmlCode    string `form:"content-html-code" valid:"omitempty"`
-	Status             int8   `form:"status" valid:"numeric"`
-	Istop              int8   `form:"istop" valid:"numeric"`
-	Cover              string `form:"cover" valid:"omitempty"`
-	UserID             int64  `form:"user_id" valid:"gte=0"`
-	ComeFrom           string `form:"come_from" valid:"omitempty"`
-	Tags               string `form:"tags" valid:"required"`
+	ID                 int64   `form:"id" valid:"omitempty,gte=0"`
+	Title              string  `form:"title" valid:"required,max=100"`
+	Color              string  `form:"color" valid:"omitempty,iscolor"`
+	Urlname            string  `form:"urlname" valid:"omitempty"`
+	Urltype            int8    `form:"urltype" valid:"omitempty,numeric"`
+	ContentMarkdownDoc string  `form:"content-markdown-doc" valid:"required"`
+	ContentHtmlCode    string  `form:"content-html-code" valid:"omitempty"`
+	Status             int8    `form:"status" valid:"numeric"`
+	Istop              int8    `form:"istop" valid:"numeric"`
+	Cover              string  `form:"cover" valid:"omitempty"`
+	UserID             int64   `form:"user_id" valid:"gte=0"`
+	ComeFrom           string  `form:"come_from" valid:"omitempty"`
+	Tags               string  `form:"tags" valid:"required"`
+	CategoryID         int64   `form:"category_id" valid:"omitempty,gte=0"`
+	Visibility         int8    `form:"visibility" valid:"omitempty,gte=0,lte=3"`
+	Password           string  `form:"password" valid:"omitempty,max=50"`
+	RoleIDs            []int64 `form:"role_ids" valid:"omitempty"`
 }
 
 type Articles struct {
@@ -78,9 +112,10 @@ type ArticleTags struct {
 // 带颜色的标题
 func (a *Article) ColorTitle() string {
 	if a.Color != "" {
-		return fmt.Sprintf("<div style=\"color:%s\">%s</div>", a.Color, a.Title)
+		return fmt.Sprintf("<div style=\"color:%s\">%s</div>",
+			html.EscapeString(a.Color), html.EscapeString(a.Title))
 	} else {
-		return a.Title
+		return html.EscapeString(a.Title)
 	}
 }
 
@@ -106,6 +141,14 @@ func (a *Article) TagsLink() string {
 
 //摘要
 func (a *Article) Excerpt() string {
+	//优先使用渲染后的纯文本
+	if a.PlainText != "" {
+		data := []rune(a.PlainText)
+		if len(data) > 160 {
+			return string(data[:160]) + "..."
+		}
+		return a.PlainText
+	}
 	//将HTML标签全转换成小写
 	re, _ := regexp.Compile("<[\\S\\s]+?>")
 	rep := re.ReplaceAllStringFunc(a.Content, strings.ToLower)
@@ -129,6 +172,39 @@ func (a *Article) Excerpt() string {
 	return rep
 }
 
+// 解析标题大纲
+func (a *Article) IsPublic() bool {
+	return a.Visibility == VisibilityPublic
+}
+
+func (a *Article) HasRole(id int64) bool {
+	for _, v := range ParseIDs(a.RoleIDs) {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Lock 隐藏正文，仅保留摘要作为预览
+func (a *Article) Lock() {
+	a.PlainText = a.Excerpt()
+	a.Content = ""
+	a.HTML = ""
+	a.TOC = ""
+	a.Outline = ""
+	a.Headings = nil
+	a.Words = 0
+	a.Locked = true
+}
+
+func (a *Article) LoadOutline() {
+	a.Headings = nil
+	if a.Outline != "" {
+		_ = json.Unmarshal([]byte(a.Outline), &a.Headings)
+	}
+}
+
 func (a *Article) TagsToString() string {
 	var list []string
 	for _, tag := range a.Tags {
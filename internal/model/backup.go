@@ -0,0 +1,30 @@
+package model
+
+// Tables 全部数据表对应的模型，用于建表与备份恢复，新增模型需加入此列表
+var Tables = []interface{}{
+	new(Options),
+	new(User),
+	new(Role),
+	new(Policy),
+	new(Article),
+	new(Tag),
+	new(TagAlias),
+	new(Mood),
+	new(Link),
+	new(Comment),
+	new(Album),
+	new(Photo),
+	new(StatDaily),
+	new(StatItem),
+	new(Reaction),
+	new(Series),
+	new(SeriesArticle),
+	new(Category),
+	new(Redirect),
+}
+
+// BackupTable 单个数据表的备份，行数据以列名为键
+type BackupTable struct {
+	Name string                   `json:"name"`
+	Rows []map[string]interface{} `json:"rows"`
+}
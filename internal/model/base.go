@@ -13,11 +13,15 @@ type GiNana struct {
 }
 
 type JSON struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Code      int         `json:"code"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	RequestID string      `json:"request_id,omitempty"` // 出错时返回，用于在日志中查找该请求
 }
 
+// RequestUserKey 控制器通过ctx.Values()传给访问日志的登录用户ID
+const RequestUserKey = "requestUserID"
+
 // 分页器
 type Pager struct {
 	pager.Pager
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// 分类模型，树形结构，与自由标签相互独立
+type Category struct {
+	ID            int64       `json:"id" gorm:"primary_key;comment:'分类ID'"`
+	CreatedAt     time.Time   `json:"created_at" gorm:"comment:'创建时间'"`
+	UpdatedAt     time.Time   `json:"updated_at" gorm:"comment:'更新时间'"`
+	ParentID      int64       `json:"parent_id" gorm:"index;not null;comment:'上级分类ID'"`
+	Name          string      `json:"name" gorm:"type:VARCHAR(50);not null;comment:'分类名称'"`
+	Slug          string      `json:"slug" gorm:"type:VARCHAR(100);unique;not null;comment:'分类别名'"`
+	Description   string      `json:"description" gorm:"type:VARCHAR(500);not null;comment:'分类描述'"`
+	Cover         string      `json:"cover" gorm:"type:VARCHAR(255);not null;default:'/static/upload/default/blog-default-0.png';comment:'分类封面'"`
+	Sort          int         `json:"sort" gorm:"not null;comment:'排序'"`
+	Children      []*Category `json:"children" gorm:"-"`
+	Depth         int         `json:"-" gorm:"-"`
+	CountArticles int64       `json:"count_articles" gorm:"-"`
+}
+
+type CreateCategoryReq struct {
+	ParentID    int64  `form:"parent_id" valid:"omitempty,gte=0"`
+	Name        string `form:"name" valid:"required,max=50"`
+	Slug        string `form:"slug" valid:"omitempty,max=100"`
+	Description string `form:"description" valid:"omitempty,max=500"`
+	Cover       string `form:"cover" valid:"omitempty"`
+	Sort        int    `form:"sort" valid:"omitempty"`
+}
+
+type UpdateCategoryReq struct {
+	ID          int64  `form:"id" valid:"required,gt=0"`
+	ParentID    int64  `form:"parent_id" valid:"omitempty,gte=0"`
+	Name        string `form:"name" valid:"required,max=50"`
+	Slug        string `form:"slug" valid:"omitempty,max=100"`
+	Description string `form:"description" valid:"omitempty,max=500"`
+	Cover       string `form:"cover" valid:"omitempty"`
+	Sort        int    `form:"sort" valid:"omitempty"`
+}
+
+// CategorySortReq 拖拽排序后的节点位置
+type CategorySortReq struct {
+	ID       int64 `json:"id"`
+	ParentID int64 `json:"parent_id"`
+	Sort     int   `json:"sort"`
+}
+
+// CategoryTree 分类树
+type CategoryTree struct {
+	Roots []*Category         `json:"roots"`
+	Index map[int64]*Category `json:"-"`
+}
+
+func (c *Category) Link() string {
+	return "/c/" + c.Slug
+}
+
+// Indent 按层级缩进的名称，用于下拉选择
+func (c *Category) Indent() string {
+	return strings.Repeat("　", c.Depth) + c.Name
+}
+
+// NewCategoryTree 由扁平列表构建分类树，同级按Sort、ID排序
+func NewCategoryTree(list []*Category) *CategoryTree {
+	t := &CategoryTree{Index: make(map[int64]*Category, len(list))}
+	for _, c := range list {
+		c.Children = nil
+		t.Index[c.ID] = c
+	}
+	for _, c := range list {
+		if p, ok := t.Index[c.ParentID]; ok && p.ID != c.ID {
+			p.Children = append(p.Children, c)
+		} else {
+			t.Roots = append(t.Roots, c)
+		}
+	}
+	t.walk(t.Roots, 0)
+	return t
+}
+
+func (t *CategoryTree) walk(list []*Category, depth int) {
+	for _, c := range list {
+		c.Depth = depth
+		t.walk(c.Children, depth+1)
+	}
+}
+
+// Flatten 按树的先序遍历展开
+func (t *CategoryTree) Flatten() (list []*Category) {
+	var walk func([]*Category)
+	walk = func(cs []*Category) {
+		for _, c := range cs {
+			list = append(list, c)
+			walk(c.Children)
+		}
+	}
+	walk(t.Roots)
+	return
+}
+
+// Breadcrumbs 从根分类到当前分类的路径
+func (t *CategoryTree) Breadcrumbs(id int64) (list []*Category) {
+	seen := make(map[int64]bool)
+	for c, ok := t.Index[id]; ok && !seen[c.ID]; c, ok = t.Index[c.ParentID] {
+		seen[c.ID] = true
+		list = append([]*Category{c}, list...)
+	}
+	return
+}
+
+// Descendants 当前分类及其所有子分类的ID
+func (t *CategoryTree) Descendants(id int64) (ids []int64) {
+	c, ok := t.Index[id]
+	if !ok {
+		return
+	}
+	var walk func(*Category)
+	walk = func(c *Category) {
+		ids = append(ids, c.ID)
+		for _, child := range c.Children {
+			walk(child)
+		}
+	}
+	walk(c)
+	return
+}
@@ -0,0 +1,12 @@
+package model
+
+// ExportData 导出的全部内容
+type ExportData struct {
+	Articles       []*Article
+	Comments       map[int64][]*Comment // 文章ID -> 评论，0 为友链页评论
+	Moods          []*Mood
+	Tags           []*Tag
+	Categories     []*Category
+	Series         []*Series
+	SeriesArticles []*SeriesArticle // 按系列与顺序排列
+}
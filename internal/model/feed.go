@@ -0,0 +1,89 @@
+package model
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// RSS 2.0 订阅
+type RSS struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	Channel *RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	Language      string     `xml:"language"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	Items         []*RSSItem `xml:"item"`
+}
+
+type RSSItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Guid        string `xml:"guid"`
+	Author      string `xml:"author,omitempty"`
+	Category    string `xml:"category,omitempty"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description"`
+}
+
+func NewRSS(options *Option, articles []*Article) *RSS {
+	siteURL := strings.TrimRight(options.SiteURL, "/")
+	channel := &RSSChannel{
+		Title:         options.SiteName,
+		Link:          siteURL,
+		Description:   options.Description,
+		Language:      "zh-cn",
+		LastBuildDate: time.Now().Format(time.RFC1123Z),
+	}
+	for _, a := range articles {
+		item := &RSSItem{
+			Title:       a.Title,
+			Link:        siteURL + a.Link(),
+			Guid:        siteURL + a.Link(),
+			Category:    a.TagsToString(),
+			PubDate:     a.CreatedAt.Format(time.RFC1123Z),
+			Description: a.HTML,
+		}
+		if a.User != nil {
+			item.Author = a.User.Nickname
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	return &RSS{Version: "2.0", Channel: channel}
+}
+
+// Sitemap 站点地图
+type Sitemap struct {
+	XMLName xml.Name      `xml:"urlset"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	URLs    []*SitemapURL `xml:"url"`
+}
+
+type SitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+func NewSitemap(options *Option, articles []*Article) *Sitemap {
+	siteURL := strings.TrimRight(options.SiteURL, "/")
+	sitemap := &Sitemap{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, page := range []string{"/", "/life.html", "/category.html", "/archives", "/about.html"} {
+		sitemap.URLs = append(sitemap.URLs, &SitemapURL{Loc: siteURL + page, ChangeFreq: "daily", Priority: "1.0"})
+	}
+	for _, a := range articles {
+		sitemap.URLs = append(sitemap.URLs, &SitemapURL{
+			Loc:      siteURL + a.Link(),
+			LastMod:  a.UpdatedAt.Format("2006-01-02"),
+			Priority: "0.8",
+		})
+	}
+	return sitemap
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// 支持导入的格式
+const (
+	ImportWXR   = "wxr"   // WordPress 导出的 WXR 文件
+	ImportHexo  = "hexo"  // Hexo 的 Markdown 目录
+	ImportHugo  = "hugo"  // Hugo 的 Markdown 目录
+	ImportGhost = "ghost" // Ghost 导出的 JSON 文件
+)
+
+var ImportFormats = []string{ImportWXR, ImportHexo, ImportHugo, ImportGhost}
+
+// ImportData 各格式解析后的统一中间结构
+type ImportData struct {
+	Format   string
+	Users    []*ImportUser
+	Tags     []*ImportTag
+	Posts    []*ImportPost
+	Images   int      // 已改写（或试运行时将改写）的图片数
+	Warnings []string // 解析与图片处理过程中的警告
+}
+
+type ImportUser struct {
+	Key      string // 原站中的标识，用于关联文章与评论
+	Username string
+	Nickname string
+	Email    string
+}
+
+// ImportTag 带URL别名与描述的标签，仅文章中引用的名称不必列出
+type ImportTag struct {
+	Name        string
+	Slug        string
+	Description string
+}
+
+type ImportPost struct {
+	Key       string
+	Source    string // 来源文件或条目，用于报告
+	Title     string
+	Slug      string
+	Content   string // Markdown，可包含HTML
+	Cover     string
+	Author    string // 对应 ImportUser.Key
+	Draft     bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Tags      []string
+	Aliases   []string // 原站链接路径，导入后跳转到新链接
+	Comments  []*ImportComment
+}
+
+type ImportComment struct {
+	Key       string
+	ParentKey string
+	User      string // 原站注册用户，对应 ImportUser.Key
+	Author    string // 游客昵称
+	Email     string
+	IP        string
+	Content   string
+	CreatedAt time.Time
+}
+
+func (d *ImportData) Warnf(format string, args ...interface{}) {
+	d.Warnings = append(d.Warnings, fmt.Sprintf(format, args...))
+}
+
+// ImportReport 导入结果，试运行时为预计结果
+type ImportReport struct {
+	Format    string        `json:"format"`
+	DryRun    bool          `json:"dry_run"`
+	Articles  int           `json:"articles"`
+	Skipped   int           `json:"skipped"`
+	Tags      int           `json:"tags"`
+	Users     int           `json:"users"`
+	Comments  int           `json:"comments"`
+	Images    int           `json:"images"`
+	Redirects int           `json:"redirects"`
+	Items     []*ImportItem `json:"items"`
+	Warnings  []string      `json:"warnings"`
+}
+
+type ImportItem struct {
+	Title    string `json:"title"`
+	Source   string `json:"source"`
+	Link     string `json:"link"`
+	Skipped  bool   `json:"skipped"`
+	Comments int    `json:"comments"`
+	Note     string `json:"note"`
+}
+
+func (r *ImportReport) Warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// IsImportFormat 是否为支持的导入格式
+func IsImportFormat(format string) bool {
+	for _, v := range ImportFormats {
+		if v == format {
+			return true
+		}
+	}
+	return false
+}
@@ -12,6 +12,7 @@ type Mood struct {
 	CreatedAt time.Time `json:"created_at" gorm:"comment:'创建时间'"`
 	Content   string    `json:"content" form:"content" gorm:"type:LONGTEXT;not null;comment:'心情内容'"`
 	Cover     string    `json:"cover" form:"cover" gorm:"type:VARCHAR(100);comment:'心情插图'"`
+	HTML      string    `json:"html" gorm:"-"` // 服务端渲染并过滤后的内容
 }
 
 type MoodReq struct {
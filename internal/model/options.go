@@ -1,6 +1,9 @@
 package model
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+)
 
 type Options struct {
 	ID    int64  `json:"id" gorm:"primary_key;comment:'设置ID'"`
@@ -19,6 +22,8 @@ type Option struct {
 	WeiBo        string `form:"wei_bo"`
 	Github       string `form:"github"`
 	AlbumSize    string `form:"album_size"`
+	RelatedSize  string `form:"related_size"`
+	Reactions    string `form:"reactions"`
 	Nickname     string `form:"nickname"`
 	MyOldCity    string `form:"my_old_city"`
 	MyCity       string `form:"my_city"`
@@ -35,5 +40,25 @@ func (o *Option) GetNickname(p ...int) string {
 		def = p[0]
 	}
 	list := strings.Split(o.Nickname, "|")
+	if def >= len(list) {
+		def = 0
+	}
 	return list[def]
 }
+
+// GetRelatedSize 相关文章数量，未设置时默认5篇
+func (o *Option) GetRelatedSize() int {
+	size, err := strconv.Atoi(o.RelatedSize)
+	if err != nil || size < 0 {
+		return 5
+	}
+	return size
+}
+
+// GetReactions 文章可用的表情，未设置时使用默认表情
+func (o *Option) GetReactions() []*ReactionType {
+	if list := ParseReactions(o.Reactions); len(list) > 0 {
+		return list
+	}
+	return ParseReactions(DefReactions)
+}
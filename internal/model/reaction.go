@@ -0,0 +1,54 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// 默认表情，格式：名称:表情|名称:表情
+const DefReactions = "like:👍|love:❤️|laugh:😄|wow:😮|sad:😢"
+
+// Reaction 文章点赞与表情回应，登录用户按UserID去重，游客的Cookie与IP任一已回应过即不能再回应
+type Reaction struct {
+	ID        int64     `json:"id" gorm:"primary_key;comment:'回应ID'"`
+	CreatedAt time.Time `json:"created_at" gorm:"comment:'创建时间'"`
+	ArticleID int64     `json:"article_id" gorm:"unique_index:uix_reaction;not null;comment:'关联文章ID'"`
+	Name      string    `json:"name" gorm:"type:VARCHAR(20);unique_index:uix_reaction;not null;comment:'表情名称'"`
+	UserID    int64     `json:"user_id" gorm:"unique_index:uix_reaction;not null;comment:'关联用户ID'"`
+	Visitor   string    `json:"-" gorm:"type:CHAR(64);unique_index:uix_reaction;not null;comment:'游客Cookie标识的哈希'"`
+	IPHash    string    `json:"-" gorm:"type:CHAR(64);index;not null;default:'';comment:'游客IP的哈希'"`
+}
+
+// ReactionType 可用的表情
+type ReactionType struct {
+	Name  string `json:"name"`
+	Emoji string `json:"emoji"`
+}
+
+// ReactionCount 文章某个表情的回应数
+type ReactionCount struct {
+	Name    string `json:"name"`
+	Emoji   string `json:"emoji"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted"` // 当前访客是否已回应
+}
+
+type ReactionReq struct {
+	ArticleID int64  `valid:"required,gt=0"`
+	Name      string `form:"name" json:"name" valid:"required"`
+	UserID    int64  // 登录用户ID
+	VisitorID string // 游客Cookie标识
+	IP        string
+}
+
+// ParseReactions 解析表情设置，格式错误的项会被忽略
+func ParseReactions(s string) (list []*ReactionType) {
+	for _, item := range strings.Split(s, "|") {
+		kv := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			continue
+		}
+		list = append(list, &ReactionType{Name: strings.TrimSpace(kv[0]), Emoji: strings.TrimSpace(kv[1])})
+	}
+	return
+}
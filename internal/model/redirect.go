@@ -0,0 +1,69 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Redirect 跳转规则，Source 可包含一个通配符 *，匹配的部分替换 Target 中的 *
+type Redirect struct {
+	ID        int64      `json:"id" gorm:"primary_key;comment:'跳转ID'"`
+	CreatedAt time.Time  `json:"created_at" gorm:"comment:'创建时间'"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"comment:'更新时间'"`
+	Source    string     `json:"source" gorm:"type:VARCHAR(191);unique;not null;comment:'原路径'"`
+	Target    string     `json:"target" gorm:"type:VARCHAR(255);not null;comment:'目标地址'"`
+	Code      int        `json:"code" gorm:"not null;default:301;comment:'状态码'"` // 301或302
+	Auto      bool       `json:"auto" gorm:"not null;default:false;comment:'文章链接变更时自动生成'"`
+	Hits      int64      `json:"hits" gorm:"not null;default:0;comment:'命中次数'"`
+	LastHitAt *time.Time `json:"last_hit_at" gorm:"comment:'最后命中时间'"`
+}
+
+type CreateRedirectReq struct {
+	Source string `form:"source" valid:"required,max=191"`
+	Target string `form:"target" valid:"required,max=255"`
+	Code   int    `form:"code" valid:"oneof=301 302"`
+}
+
+type UpdateRedirectReq struct {
+	ID     int64  `form:"id" valid:"required,gt=0"`
+	Source string `form:"source" valid:"required,max=191"`
+	Target string `form:"target" valid:"required,max=255"`
+	Code   int    `form:"code" valid:"oneof=301 302"`
+}
+
+type Redirects struct {
+	List  []*Redirect `json:"list"`
+	Pager *Pager      `json:"pager"`
+}
+
+func (r *Redirect) IsWildcard() bool {
+	return strings.Contains(r.Source, "*")
+}
+
+// Match 匹配请求路径，返回跳转地址
+func (r *Redirect) Match(path string) (target string, ok bool) {
+	i := strings.Index(r.Source, "*")
+	if i < 0 {
+		return r.Target, path == r.Source
+	}
+	prefix, suffix := r.Source[:i], r.Source[i+1:]
+	if len(path) < len(prefix)+len(suffix) || !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) {
+		return "", false
+	}
+	target = strings.Replace(r.Target, "*", path[len(prefix):len(path)-len(suffix)], 1)
+	// 匹配的部分可能使站内地址变成 //host 或 /\host，浏览器会当作站外地址
+	if strings.HasPrefix(target, "//") || strings.HasPrefix(target, `/\`) {
+		return "", false
+	}
+	return target, true
+}
+
+// Loops 跳转后的地址是否又会被本规则匹配，造成循环跳转
+func (r *Redirect) Loops() bool {
+	target := strings.Replace(r.Target, "*", "loop", 1)
+	if i := strings.IndexAny(target, "?#"); i >= 0 {
+		target = target[:i]
+	}
+	_, ok := r.Match(target)
+	return ok
+}
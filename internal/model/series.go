@@ -0,0 +1,82 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 系列模型，文章按顺序组成专栏
+type Series struct {
+	ID          int64      `json:"id" gorm:"primary_key;comment:'系列ID'"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"comment:'创建时间'"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"comment:'更新时间'"`
+	Title       string     `json:"title" gorm:"type:VARCHAR(100);unique;not null;comment:'系列标题'"`
+	Description string     `json:"description" gorm:"type:VARCHAR(500);not null;comment:'系列描述'"`
+	Cover       string     `json:"cover" gorm:"type:VARCHAR(255);not null;default:'/static/upload/default/blog-default-0.png';comment:'系列封面'"`
+	Articles    []*Article `json:"articles" gorm:"-"` // 按顺序排列的文章
+}
+
+// SeriesArticle 系列与文章的关联，一篇文章只属于一个系列
+type SeriesArticle struct {
+	ID        int64 `json:"id" gorm:"primary_key;comment:'关联ID'"`
+	SeriesID  int64 `json:"series_id" gorm:"index;not null;comment:'系列ID'"`
+	ArticleID int64 `json:"article_id" gorm:"unique_index;not null;comment:'文章ID'"`
+	Sort      int   `json:"sort" gorm:"not null;comment:'排序'"`
+}
+
+type CreateSeriesReq struct {
+	Title       string `form:"title" valid:"required"`
+	Description string `form:"description" valid:"omitempty"`
+	Cover       string `form:"cover" valid:"omitempty"`
+	ArticleIDs  string `form:"article_ids" valid:"omitempty"` // 按顺序以逗号分隔的文章ID
+}
+
+type UpdateSeriesReq struct {
+	ID          int64  `form:"id" valid:"required,gt=0"`
+	Title       string `form:"title" valid:"required"`
+	Description string `form:"description" valid:"omitempty"`
+	Cover       string `form:"cover" valid:"omitempty"`
+	ArticleIDs  string `form:"article_ids" valid:"omitempty"`
+}
+
+type SeriesList struct {
+	List  []*Series `json:"list"`
+	Pager *Pager    `json:"pager"`
+}
+
+// SeriesNav 文章所在系列的导航
+type SeriesNav struct {
+	Series   *Series  `json:"series"`
+	Position int      `json:"position"` // 从1开始
+	Total    int      `json:"total"`
+	Prev     *Article `json:"prev"`
+	Next     *Article `json:"next"`
+}
+
+// ParseIDs 解析逗号分隔的ID，忽略无效与重复的ID并保持顺序
+func ParseIDs(s string) (ids []int64) {
+	seen := make(map[int64]bool)
+	for _, v := range strings.Split(s, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil || id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return
+}
+
+func (s *Series) Link() string {
+	return "/series/" + strconv.FormatInt(s.ID, 10)
+}
+
+// ArticleIDs 文章ID，用于后台编辑
+func (s *Series) ArticleIDs() string {
+	list := make([]string, 0, len(s.Articles))
+	for _, a := range s.Articles {
+		list = append(list, strconv.FormatInt(a.ID, 10))
+	}
+	return strings.Join(list, ",")
+}
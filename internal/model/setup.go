@@ -0,0 +1,24 @@
+package model
+
+// 首次安装时写入的默认站点设置，站点名称、地址与主题由安装向导或种子文件填写
+var DefOptions = map[string]string{
+	"SiteName":    "GiNana",
+	"PageSize":    "15",
+	"Theme":       "main",
+	"AlbumSize":   "9",
+	"RelatedSize": "5",
+	"Reactions":   DefReactions,
+}
+
+// SetupReq 首次安装，创建管理员并填写站点信息
+type SetupReq struct {
+	Username      string            `form:"username" toml:"username" valid:"required"`
+	Password      string            `form:"password" toml:"password" valid:"required,gte=6"`
+	PasswordAgain string            `form:"password_again" toml:"-" valid:"omitempty,eqfield=Password"`
+	Nickname      string            `form:"nickname" toml:"nickname" valid:"omitempty"`
+	Email         string            `form:"email" toml:"email" valid:"omitempty,email"`
+	SiteName      string            `form:"site_name" toml:"siteName" valid:"required"`
+	SiteURL       string            `form:"site_url" toml:"siteURL" valid:"omitempty,url"`
+	Theme         string            `form:"theme" toml:"theme" valid:"omitempty"`
+	Options       map[string]string `form:"-" toml:"options"` // 其他站点设置，键为 Option 的字段名
+}
@@ -0,0 +1,75 @@
+package model
+
+// 分项统计类型
+const (
+	StatKindPath     = "path"     // 受访页面
+	StatKindReferrer = "referrer" // 来源站点
+	StatKindArticle  = "article"  // 文章，Name为文章ID
+	StatKindTag      = "tag"      // 标签，Name为标签ID
+	StatKindNotFound = "404"      // 不存在的页面
+	StatKindDevice   = "device"   // 设备类型
+)
+
+// 控制器通过ctx.Values()传给统计中间件的数据
+const (
+	StatArticleKey = "statArticleID"
+	StatTagsKey    = "statTagIDs"
+)
+
+// StatDaily 每日访问汇总
+type StatDaily struct {
+	ID        int64  `json:"id" gorm:"primary_key;comment:'统计ID'"`
+	Date      string `json:"date" gorm:"type:CHAR(10);unique_index;not null;comment:'日期'"`
+	PageViews int64  `json:"page_views" gorm:"not null;comment:'浏览量'"`
+	Visitors  int64  `json:"visitors" gorm:"not null;comment:'独立访客'"`
+	Percent   int    `json:"-" gorm:"-"`
+}
+
+// StatItem 每日分项统计
+type StatItem struct {
+	ID    int64  `json:"id" gorm:"primary_key;comment:'统计ID'"`
+	Date  string `json:"date" gorm:"type:CHAR(10);unique_index:uix_stat_item;not null;comment:'日期'"`
+	Kind  string `json:"kind" gorm:"type:VARCHAR(20);unique_index:uix_stat_item;not null;comment:'统计类型'"`
+	Name  string `json:"name" gorm:"type:VARCHAR(200);unique_index:uix_stat_item;not null;comment:'统计项'"`
+	Count int64  `json:"count" gorm:"not null;comment:'次数'"`
+}
+
+// StatHit 一次页面访问
+type StatHit struct {
+	Path      string
+	Referrer  string
+	Host      string
+	IP        string
+	UserAgent string
+	Status    int
+	ArticleID int64
+	TagIDs    []int64
+}
+
+type StatQueryParam struct {
+	Start string // 开始日期，如 2020-01-01
+	End   string // 结束日期
+}
+
+// StatRank 分项排行
+type StatRank struct {
+	Name    string `json:"name"`
+	Title   string `json:"title"`
+	Count   int64  `json:"count"`
+	Percent int    `json:"-"`
+}
+
+// StatReport 统计报表
+type StatReport struct {
+	Start     string       `json:"start"`
+	End       string       `json:"end"`
+	PageViews int64        `json:"page_views"`
+	Visitors  int64        `json:"visitors"`
+	Days      []*StatDaily `json:"days"`
+	Paths     []*StatRank  `json:"paths"`
+	Referrers []*StatRank  `json:"referrers"`
+	Articles  []*StatRank  `json:"articles"`
+	Tags      []*StatRank  `json:"tags"`
+	NotFound  []*StatRank  `json:"not_found"`
+	Devices   []*StatRank  `json:"devices"`
+}
@@ -3,14 +3,37 @@ package model
 import (
 	"fmt"
 	"math/rand"
+	"net/url"
+	"strings"
 	"time"
 )
 
 type Tag struct {
-	ID        int64      `json:"id" gorm:"primary_key;comment:'标签ID'"`
-	CreatedAt time.Time  `json:"created_at" gorm:"comment:'创建时间'"`
-	Name      string     `json:"name" gorm:"type:VARCHAR(50);unique;index;not null;comment:'标签名称'"`
-	Articles  []*Article `json:"articles" gorm:"many2many:article_tags"`
+	ID          int64       `json:"id" gorm:"primary_key;comment:'标签ID'"`
+	CreatedAt   time.Time   `json:"created_at" gorm:"comment:'创建时间'"`
+	Name        string      `json:"name" gorm:"type:VARCHAR(50);unique;index;not null;comment:'标签名称'"`
+	Slug        string      `json:"slug" gorm:"type:VARCHAR(100);index;comment:'URL别名'"`
+	Description string      `json:"description" gorm:"type:VARCHAR(255);comment:'标签描述'"`
+	Cover       string      `json:"cover" gorm:"type:VARCHAR(255);comment:'标签封面'"`
+	Aliases     []*TagAlias `json:"aliases" gorm:"foreignkey:TagID"`
+	Articles    []*Article  `json:"articles" gorm:"many2many:article_tags"`
+}
+
+// TagAlias 标签同义词，Name 保存规范化后的小写形式
+type TagAlias struct {
+	ID        int64     `json:"id" gorm:"primary_key;comment:'同义词ID'"`
+	CreatedAt time.Time `json:"created_at" gorm:"comment:'创建时间'"`
+	Name      string    `json:"name" gorm:"type:VARCHAR(50);unique;not null;comment:'同义词'"`
+	TagID     int64     `json:"tag_id" gorm:"index;not null;comment:'标签ID'"`
+}
+
+type UpdateTagReq struct {
+	ID          int64  `form:"id" valid:"omitempty"`
+	Name        string `form:"name" valid:"required"`
+	Slug        string `form:"slug" valid:"omitempty"`
+	Description string `form:"description" valid:"omitempty"`
+	Cover       string `form:"cover" valid:"omitempty"`
+	Aliases     string `form:"aliases" valid:"omitempty"`
 }
 
 type TagQueryParam struct {
@@ -30,7 +53,23 @@ type TagListReq struct {
 }
 
 func (t *Tag) Link() string {
-	return fmt.Sprintf("<a class=\"category\" href=\"/category/%d\"><span class=\"badge\">%s</span></a>", t.ID, t.Name)
+	return fmt.Sprintf("<a class=\"category\" href=\"%s\"><span class=\"badge\">%s</span></a>", t.URL(), t.Name)
+}
+
+// URL 标签页地址，未设置别名时使用标签名
+func (t *Tag) URL() string {
+	if t.Slug != "" {
+		return "/tag/" + t.Slug
+	}
+	return "/tag/" + url.PathEscape(t.Name)
+}
+
+func (t *Tag) AliasesToString() string {
+	names := make([]string, 0, len(t.Aliases))
+	for _, a := range t.Aliases {
+		names = append(names, a.Name)
+	}
+	return strings.Join(names, ",")
 }
 
 func (t *Tag) CountArticles() int {
@@ -47,3 +86,37 @@ func (t *Tag) RangeColor() string {
 	}
 	return "#" + str
 }
+
+// NormalizeTagName 全角转半角，去除首尾空白并合并连续空白
+func NormalizeTagName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == 0x3000:
+			return ' '
+		case r >= 0xFF01 && r <= 0xFF5E:
+			return r - 0xFEE0
+		}
+		return r
+	}, name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// TagKey 标签比较用的键，规范化后转小写
+func TagKey(name string) string {
+	return strings.ToLower(NormalizeTagName(name))
+}
+
+// SplitTagNames 拆分逗号分隔的标签，支持全角逗号，忽略空值与大小写重复
+func SplitTagNames(s string) (names []string) {
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(NormalizeTagName(s), ",") {
+		name = NormalizeTagName(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+	return
+}
@@ -0,0 +1,174 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 相关度权重：标签重合度与正文TF-IDF余弦相似度
+const (
+	TagWeight  = 0.4
+	TextWeight = 0.6
+)
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true,
+	"http": true, "https": true, "www": true, "com": true, "static": true, "upload": true,
+	"的": true, "了": true, "是": true, "在": true,
+}
+
+// Doc 参与计算的文章
+type Doc struct {
+	ID   int64
+	Text string
+	Tags []string
+}
+
+// Score 相关度得分
+type Score struct {
+	ID    int64
+	Score float64
+}
+
+// Related 计算与target最相关的文章，按得分从高到低返回，最多limit篇
+func Related(target int64, docs []*Doc, limit int) (list []*Score) {
+	var cur *Doc
+	for _, d := range docs {
+		if d.ID == target {
+			cur = d
+			break
+		}
+	}
+	if cur == nil || limit <= 0 {
+		return
+	}
+	vectors := tfidf(docs)
+	for i, d := range docs {
+		if d.ID == target {
+			continue
+		}
+		score := TagWeight*jaccard(cur.Tags, d.Tags) + TextWeight*cosine(vectors[cur.ID], vectors[docs[i].ID])
+		if score > 0 {
+			list = append(list, &Score{ID: d.ID, Score: score})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Score == list[j].Score {
+			return list[i].ID > list[j].ID
+		}
+		return list[i].Score > list[j].Score
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return
+}
+
+// Tokenize 分词：英文按单词，中日韩文字按二元组
+func Tokenize(s string) (tokens []string) {
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 1 {
+			w := string(word)
+			if !stopWords[w] {
+				tokens = append(tokens, w)
+			}
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 && !stopWords[string(cjk)] {
+			tokens = append(tokens, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return
+}
+
+func tfidf(docs []*Doc) map[int64]map[string]float64 {
+	df := make(map[string]int)
+	tfs := make(map[int64]map[string]float64, len(docs))
+	for _, d := range docs {
+		tf := make(map[string]float64)
+		tokens := Tokenize(d.Text)
+		for _, t := range tokens {
+			tf[t]++
+		}
+		for t := range tf {
+			df[t]++
+			tf[t] /= float64(len(tokens))
+		}
+		tfs[d.ID] = tf
+	}
+	n := float64(len(docs))
+	for _, tf := range tfs {
+		for t := range tf {
+			tf[t] *= math.Log(1 + n/float64(df[t]))
+		}
+	}
+	return tfs
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for t, v := range a {
+		na += v * v
+		if w, ok := b[t]; ok {
+			dot += v * w
+		}
+	}
+	for _, w := range b {
+		nb += w * w
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(a))
+	for _, t := range a {
+		set[strings.ToLower(t)] = true
+	}
+	inter := 0
+	union := len(set)
+	seen := make(map[string]bool, len(b))
+	for _, t := range b {
+		t = strings.ToLower(t)
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		if set[t] {
+			inter++
+		} else {
+			union++
+		}
+	}
+	return float64(inter) / float64(union)
+}
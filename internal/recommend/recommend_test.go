@@ -0,0 +1,30 @@
+package recommend
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	Convey("Tokenize", t, func() {
+		So(Tokenize("Go语言并发, the goroutine!"), ShouldResemble,
+			[]string{"go", "语言", "言并", "并发", "goroutine"})
+	})
+}
+
+func TestRelated(t *testing.T) {
+	Convey("Related", t, func() {
+		docs := []*Doc{
+			{ID: 1, Text: "Golang 并发编程 goroutine channel", Tags: []string{"Go"}},
+			{ID: 2, Text: "goroutine 与 channel 的并发模型", Tags: []string{"go"}},
+			{ID: 3, Text: "旅行日记：武汉的樱花", Tags: []string{"旅行"}},
+			{ID: 4, Text: "MySQL 索引优化", Tags: []string{"Go"}},
+		}
+		list := Related(1, docs, 5)
+		So(len(list), ShouldEqual, 2)
+		So(list[0].ID, ShouldEqual, 2)
+		So(list[1].ID, ShouldEqual, 4)
+		So(Related(1, docs, 1), ShouldHaveLength, 1)
+		So(Related(9, docs, 5), ShouldBeEmpty)
+	})
+}
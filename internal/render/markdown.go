@@ -0,0 +1,217 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	nethtml "golang.org/x/net/html"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// 代码高亮样式，对应 static/theme/main/css/chroma.css
+const HighlightStyle = "github"
+
+var md = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		extension.Footnote,
+		highlighting.NewHighlighting(
+			highlighting.WithStyle(HighlightStyle),
+			highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+		),
+	),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+		parser.WithASTTransformers(util.Prioritized(&lazyImage{}, 100)),
+	),
+	// 原文中的HTML交由sanitize统一过滤
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// Result Markdown渲染结果
+type Result struct {
+	HTML        string     // 过滤后的正文HTML
+	TOC         string     // 目录HTML，无标题时为空
+	Text        string     // 纯文本，用于摘要
+	Outline     []*Heading // 标题大纲
+	WordCount   int        // 字数
+	ReadingTime int        // 预计阅读时长（分钟）
+}
+
+// Heading 文章标题大纲
+type Heading struct {
+	Level int    `json:"level"`
+	Title string `json:"title"`
+	ID    string `json:"id"`
+}
+
+// Render 将Markdown渲染为HTML，并按内容类型过滤
+// 传入短代码注册表时先展开短代码，展开结果不经过过滤
+func Render(kind, src string, scs ...*Shortcodes) (res *Result, err error) {
+	ph := newPlaceholders()
+	if len(scs) > 0 && scs[0] != nil {
+		src = scs[0].expand(kind, src, ph)
+	}
+	source := []byte(src)
+	ctx := parser.NewContext(parser.WithIDs(newIDs()))
+	doc := md.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+	var buf bytes.Buffer
+	if err = md.Renderer().Render(&buf, source, doc); err != nil {
+		return
+	}
+	res = new(Result)
+	res.HTML = ph.restore(HTML(kind, buf.String()), true)
+	res.Outline = headings(doc, source)
+	res.TOC = renderTOC(res.Outline)
+	res.Text = PlainText(res.HTML)
+	cjk, words := CountWords(res.Text)
+	res.WordCount = cjk + words
+	res.ReadingTime = ReadingTime(cjk, words)
+	return
+}
+
+// PlainText 提取HTML中的纯文本，连续空白合并为一个空格
+func PlainText(s string) string {
+	var buf strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			if z.Err() == io.EOF {
+				break
+			}
+			return strings.Join(strings.Fields(buf.String()), " ")
+		}
+		switch tt {
+		case nethtml.TextToken:
+			buf.Write(z.Text())
+		case nethtml.StartTagToken, nethtml.EndTagToken, nethtml.SelfClosingTagToken:
+			buf.WriteByte(' ')
+		}
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// 收集文档中的标题
+func headings(doc ast.Node, source []byte) (list []*Heading) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		h, ok := n.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		heading := &Heading{Level: h.Level, Title: string(h.Text(source))}
+		if id, ok := h.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				heading.ID = string(b)
+			}
+		}
+		list = append(list, heading)
+		return ast.WalkSkipChildren, nil
+	})
+	return
+}
+
+// 生成嵌套的目录列表
+func renderTOC(list []*Heading) string {
+	if len(list) == 0 {
+		return ""
+	}
+	min := list[0].Level
+	for _, h := range list {
+		if h.Level < min {
+			min = h.Level
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`<ul class="toc">`)
+	depth := 0
+	for i, h := range list {
+		// 跳级的标题只向下缩进一层
+		level := h.Level - min
+		if level > depth+1 {
+			level = depth + 1
+		}
+		if i > 0 {
+			if level > depth {
+				buf.WriteString("<ul>")
+				depth++
+			} else {
+				buf.WriteString("</li>")
+				for ; depth > level; depth-- {
+					buf.WriteString("</ul></li>")
+				}
+			}
+		}
+		buf.WriteString(fmt.Sprintf(`<li><a href="#%s">%s</a>`,
+			nethtml.EscapeString(h.ID), nethtml.EscapeString(h.Title)))
+	}
+	buf.WriteString("</li>")
+	for ; depth > 0; depth-- {
+		buf.WriteString("</ul></li>")
+	}
+	buf.WriteString("</ul>")
+	return buf.String()
+}
+
+// 图片默认懒加载
+type lazyImage struct{}
+
+func (t *lazyImage) Transform(doc *ast.Document, _ text.Reader, _ parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering && n.Kind() == ast.KindImage {
+			n.SetAttributeString("loading", []byte("lazy"))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// 标题锚点，保留中文等非ASCII字符
+type ids struct {
+	values map[string]bool
+}
+
+func newIDs() parser.IDs {
+	return &ids{values: make(map[string]bool)}
+}
+
+func (s *ids) Generate(value []byte, kind ast.NodeKind) []byte {
+	var buf strings.Builder
+	for _, r := range strings.TrimSpace(string(value)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			buf.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			buf.WriteRune('-')
+		}
+	}
+	result := buf.String()
+	if result == "" {
+		result = "heading"
+		if kind != ast.KindHeading {
+			result = "id"
+		}
+	}
+	id := result
+	for i := 1; s.values[id]; i++ {
+		id = fmt.Sprintf("%s-%d", result, i)
+	}
+	s.values[id] = true
+	return []byte(id)
+}
+
+func (s *ids) Put(value []byte) {
+	s.values[string(value)] = true
+}
@@ -0,0 +1,57 @@
+package render
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	Convey("Render", t, func() {
+		src := "# 简介\n\n## Go 语言\n\n### 安装\n\n## 简介\n\n" +
+			"| a | b |\n|---|---|\n| 1 | 2 |\n\n- [x] done\n\n" +
+			"脚注[^1]\n\n[^1]: 说明\n\n![图](/a.png)\n\n```go\nfunc main() {}\n```\n\n" +
+			"<img src=x onerror=alert(1)>\n"
+		res, err := Render(KindArticle, src)
+		So(err, ShouldBeNil)
+		Convey("标题锚点保留中文", func() {
+			So(res.HTML, ShouldContainSubstring, `<h2 id="go-语言">`)
+			So(res.HTML, ShouldContainSubstring, `<h2 id="简介-1">`)
+		})
+		Convey("GFM表格、任务列表与脚注", func() {
+			So(res.HTML, ShouldContainSubstring, "<table>")
+			So(res.HTML, ShouldContainSubstring, `type="checkbox"`)
+			So(res.HTML, ShouldContainSubstring, `class="footnotes"`)
+		})
+		Convey("图片懒加载与代码高亮", func() {
+			So(res.HTML, ShouldContainSubstring, `loading="lazy"`)
+			So(res.HTML, ShouldContainSubstring, `class="chroma"`)
+		})
+		Convey("过滤危险HTML", func() {
+			So(res.HTML, ShouldNotContainSubstring, "onerror")
+		})
+		Convey("生成目录", func() {
+			So(res.TOC, ShouldEqual, `<ul class="toc"><li><a href="#简介">简介</a><ul>`+
+				`<li><a href="#go-语言">Go 语言</a><ul><li><a href="#安装">安装</a></li></ul></li>`+
+				`<li><a href="#简介-1">简介</a></li></ul></li></ul>`)
+		})
+		Convey("纯文本", func() {
+			So(strings.HasPrefix(res.Text, "简介 Go 语言 安装"), ShouldBeTrue)
+		})
+	})
+}
+
+func TestCountWords(t *testing.T) {
+	Convey("CountWords", t, func() {
+		Convey("中英文混排", func() {
+			cjk, words := CountWords("使用 Go 语言编写的 blog, it's fast!")
+			So(cjk, ShouldEqual, 7)
+			So(words, ShouldEqual, 4)
+		})
+		Convey("阅读时长", func() {
+			So(ReadingTime(0, 0), ShouldEqual, 0)
+			So(ReadingTime(10, 10), ShouldEqual, 1)
+			So(ReadingTime(600, 200), ShouldEqual, 3)
+		})
+	})
+}
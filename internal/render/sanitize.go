@@ -0,0 +1,185 @@
+package render
+
+import (
+	"bytes"
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// 内容类型，决定使用哪一套白名单策略
+const (
+	KindArticle = "article" // 管理员撰写的文章
+	KindMood    = "mood"    // 管理员发布的心情
+	KindComment = "comment" // 用户评论
+)
+
+var (
+	policies = map[string]*bluemonday.Policy{
+		KindArticle: richPolicy(),
+		KindMood:    richPolicy(),
+		KindComment: strictPolicy(),
+	}
+	// 默认使用最严格的策略，避免未登记的类型被放行
+	defPolicy = strictPolicy()
+
+	// 连同内容一起丢弃的标签
+	skipContent = map[string]bool{
+		"script": true, "style": true, "iframe": true, "object": true,
+		"embed": true, "frame": true, "frameset": true, "noscript": true,
+		"textarea": true, "title": true, "xmp": true, "plaintext": true,
+	}
+
+	inlineCode = regexp.MustCompile("`[^`\n]+`")
+	// Markdown自动链接，如 <https://inana.top>、<me@inana.top>
+	autoLink = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^<>\s]*|[^<>\s@]+@[^<>\s@]+)>$`)
+)
+
+// 富文本策略：文章、心情
+func richPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Globally()
+	p.AllowAttrs("id").OnElements("h1", "h2", "h3", "h4", "h5", "h6", "li", "sup", "section")
+	p.AllowAttrs("loading").Matching(regexp.MustCompile(`^(lazy|eager|auto)$`)).OnElements("img")
+	p.AllowAttrs("type", "checked", "disabled").OnElements("input")
+	p.AllowElements("input")
+	p.AllowAttrs("src", "controls", "poster", "preload").OnElements("video", "audio", "source")
+	p.AllowElements("video", "audio", "source")
+	p.AllowAttrs("target").Matching(regexp.MustCompile(`^_blank$`)).OnElements("a")
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// 严格策略：评论，只保留少量行内标签
+func strictPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowStandardURLs()
+	p.AllowAttrs("href").OnElements("a")
+	p.RequireNoFollowOnLinks(true)
+	p.AllowElements("b", "i", "em", "strong", "code", "br", "del")
+	return p
+}
+
+func getPolicy(kind string) *bluemonday.Policy {
+	if p, ok := policies[kind]; ok {
+		return p
+	}
+	return defPolicy
+}
+
+// HTML 按内容类型过滤一段HTML，用于渲染输出
+func HTML(kind, s string) string {
+	return getPolicy(kind).Sanitize(s)
+}
+
+// SafeHTML 过滤后标记为可信HTML，供模板直接输出
+func SafeHTML(kind, s string) template.HTML {
+	return template.HTML(HTML(kind, s))
+}
+
+// Markdown 过滤Markdown原文中内嵌的HTML标签，用于保存前清洗
+// 文本原样保留，代码块、行内代码与短代码不做处理，避免破坏Markdown语法
+func Markdown(kind, s string) string {
+	p := getPolicy(kind)
+	return eachText(s, func(text string) string {
+		ph := newPlaceholders()
+		text = shortcodeTag.ReplaceAllStringFunc(text, ph.add)
+		return ph.restore(clean(p, text), false)
+	})
+}
+
+// Text 清洗纯文本类内容中的标签，文本原样保留，用于评论等保存前清洗
+func Text(kind, s string) string {
+	return clean(getPolicy(kind), s)
+}
+
+// 将代码块与行内代码替换为占位符后处理其余文本，再还原代码
+func eachText(s string, fn func(string) string) string {
+	ph := newPlaceholders()
+	var buf, code bytes.Buffer
+	fence := ""
+	for _, line := range strings.SplitAfter(s, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence == "" {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:3]
+				code.WriteString(line)
+				continue
+			}
+			buf.WriteString(line)
+			continue
+		}
+		code.WriteString(line)
+		if strings.HasPrefix(trimmed, fence) && strings.TrimSpace(strings.Trim(trimmed, fence[:1])) == "" {
+			fence = ""
+			buf.WriteString(ph.add(code.String()))
+			code.Reset()
+		}
+	}
+	if code.Len() > 0 { // 未闭合的代码块
+		buf.WriteString(ph.add(code.String()))
+	}
+	text := inlineCode.ReplaceAllStringFunc(buf.String(), ph.add)
+	return ph.restore(fn(text), false)
+}
+
+// 逐个标签交给策略判断，文本节点保持原始内容不做转义
+func clean(p *bluemonday.Policy, s string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+	var buf bytes.Buffer
+	opened := make(map[string]int)
+	skipping := ""
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				buf.Write(z.Raw())
+			}
+			break
+		}
+		raw := string(z.Raw())
+		name, _ := z.TagName()
+		tag := string(name)
+		if skipping != "" {
+			if tt == html.EndTagToken && tag == skipping {
+				skipping = ""
+			}
+			continue
+		}
+		switch tt {
+		case html.TextToken:
+			buf.WriteString(raw)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if autoLink.MatchString(raw) {
+				buf.WriteString(raw)
+				continue
+			}
+			if skipContent[tag] {
+				if tt == html.StartTagToken {
+					skipping = tag
+				}
+				continue
+			}
+			out := p.Sanitize(raw)
+			if out == "" {
+				continue
+			}
+			buf.WriteString(out)
+			if tt == html.StartTagToken {
+				opened[tag]++
+			}
+		case html.EndTagToken:
+			if opened[tag] > 0 {
+				opened[tag]--
+				buf.WriteString("</" + tag + ">")
+			}
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,39 @@
+package render
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	Convey("Markdown", t, func() {
+		Convey("去除脚本与事件属性", func() {
+			src := "# 标题\n<script>alert(1)</script>\n<img src=\"/a.png\" onerror=\"alert(1)\">\n"
+			So(Markdown(KindArticle, src), ShouldEqual, "# 标题\n\n<img src=\"/a.png\">\n")
+		})
+		Convey("保留Markdown语法与代码", func() {
+			src := "> a < b && c\n\n`<script>`\n\n```html\n<script>x</script>\n```\n<https://inana.top>\n"
+			So(Markdown(KindArticle, src), ShouldEqual, src)
+		})
+		Convey("保留短代码", func() {
+			src := "{{<note type=warn>}}提示{{</note>}}\n{{< album id=3 >}}"
+			So(Markdown(KindArticle, src), ShouldEqual, src)
+		})
+		Convey("过滤javascript链接", func() {
+			src := "<a href=\"javascript:alert(1)\">x</a>"
+			So(Markdown(KindArticle, src), ShouldEqual, "x")
+		})
+	})
+}
+
+func TestText(t *testing.T) {
+	Convey("Text", t, func() {
+		Convey("评论只保留少量行内标签", func() {
+			src := "<b>hi</b><div onclick=\"x\">1 < 2</div><iframe src=\"//x\"></iframe>"
+			So(Text(KindComment, src), ShouldEqual, "<b>hi</b>1 < 2")
+		})
+		Convey("渲染时转义文本", func() {
+			So(HTML(KindComment, "1 < 2<script>x</script>"), ShouldEqual, "1 &lt; 2")
+		})
+	})
+}
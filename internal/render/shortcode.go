@@ -0,0 +1,174 @@
+package render
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Shortcode 文章中的短代码，如 {{< album id=3 >}} 或 {{< note type=warn >}}内容{{< /note >}}
+type Shortcode struct {
+	Name   string
+	Params map[string]string
+	Inner  string // 成对短代码之间的内容，已渲染为HTML
+	Raw    string // 短代码原文
+}
+
+// ShortcodeFunc 将短代码展开为HTML，输出视为可信内容，参数需自行转义
+type ShortcodeFunc func(sc *Shortcode) (string, error)
+
+// Shortcodes 短代码注册表
+type Shortcodes struct {
+	mu    sync.RWMutex
+	funcs map[string]ShortcodeFunc
+}
+
+var (
+	shortcodeTag   = regexp.MustCompile(`\{\{<\s*(/?)([a-zA-Z][\w-]*)((?:\s+[\w-]+\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'>]+))*)\s*>\}\}`)
+	shortcodeParam = regexp.MustCompile(`([\w-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+func NewShortcodes() *Shortcodes {
+	return &Shortcodes{funcs: make(map[string]ShortcodeFunc)}
+}
+
+// Register 注册短代码，同名覆盖
+func (r *Shortcodes) Register(name string, fn ShortcodeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.funcs[name] = fn
+}
+
+func (r *Shortcodes) get(name string) (fn ShortcodeFunc, ok bool) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok = r.funcs[name]
+	return
+}
+
+// Int 获取整型参数
+func (sc *Shortcode) Int(key string, def int64) int64 {
+	var v int64
+	if _, err := fmt.Sscan(sc.Params[key], &v); err != nil {
+		return def
+	}
+	return v
+}
+
+// Get 获取字符串参数
+func (sc *Shortcode) Get(key, def string) string {
+	if v, ok := sc.Params[key]; ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// 占位符，用于暂存代码块或短代码展开结果
+type placeholders struct {
+	nonce string
+	list  []string
+}
+
+func newPlaceholders() *placeholders {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return &placeholders{nonce: hex.EncodeToString(b)}
+}
+
+func (p *placeholders) add(out string) string {
+	key := fmt.Sprintf("scph%s%d", p.nonce, len(p.list))
+	p.list = append(p.list, out)
+	return key
+}
+
+// 还原占位符，unwrap时去掉独占一段的占位符外层的<p>
+func (p *placeholders) restore(s string, unwrap bool) string {
+	for i := len(p.list) - 1; i >= 0; i-- {
+		key := fmt.Sprintf("scph%s%d", p.nonce, i)
+		if unwrap {
+			s = strings.Replace(s, "<p>"+key+"</p>", p.list[i], -1)
+		}
+		s = strings.Replace(s, key, p.list[i], -1)
+	}
+	return s
+}
+
+// 展开Markdown原文中的短代码，代码块内的短代码保持原样
+func (r *Shortcodes) expand(kind, src string, ph *placeholders) string {
+	return eachText(src, func(s string) string {
+		var buf bytes.Buffer
+		for {
+			loc := shortcodeTag.FindStringSubmatchIndex(s)
+			if loc == nil {
+				buf.WriteString(s)
+				break
+			}
+			buf.WriteString(s[:loc[0]])
+			sc := &Shortcode{
+				Name:   s[loc[4]:loc[5]],
+				Params: parseParams(s[loc[6]:loc[7]]),
+				Raw:    s[loc[0]:loc[1]],
+			}
+			rest := s[loc[1]:]
+			if s[loc[2]:loc[3]] == "/" { // 多余的结束标签
+				buf.WriteString(sc.Raw)
+				s = rest
+				continue
+			}
+			if end := closingTag(rest, sc.Name); end != nil {
+				sc.Inner = rest[:end[0]]
+				sc.Raw += rest[:end[1]]
+				rest = rest[end[1]:]
+			}
+			buf.WriteString(ph.add(r.call(kind, sc)))
+			s = rest
+		}
+		return buf.String()
+	})
+}
+
+func (r *Shortcodes) call(kind string, sc *Shortcode) string {
+	fn, ok := r.get(sc.Name)
+	if !ok {
+		return html.EscapeString(sc.Raw)
+	}
+	if sc.Inner != "" {
+		res, err := Render(kind, sc.Inner, r)
+		if err != nil {
+			return html.EscapeString(sc.Raw)
+		}
+		sc.Inner = res.HTML
+	}
+	out, err := fn(sc)
+	if err != nil {
+		return fmt.Sprintf(`<span class="sc-error" title="%s">%s</span>`,
+			html.EscapeString(err.Error()), html.EscapeString(sc.Raw))
+	}
+	return out
+}
+
+// 查找对应的结束标签
+func closingTag(s, name string) []int {
+	for _, loc := range shortcodeTag.FindAllStringSubmatchIndex(s, -1) {
+		if s[loc[2]:loc[3]] == "/" && s[loc[4]:loc[5]] == name {
+			return loc
+		}
+	}
+	return nil
+}
+
+func parseParams(s string) map[string]string {
+	params := make(map[string]string)
+	for _, m := range shortcodeParam.FindAllStringSubmatch(s, -1) {
+		params[strings.ToLower(m[1])] = m[2] + m[3] + m[4]
+	}
+	return params
+}
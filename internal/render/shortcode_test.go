@@ -0,0 +1,35 @@
+package render
+
+import (
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"html"
+	"testing"
+)
+
+func TestShortcodes(t *testing.T) {
+	Convey("Shortcodes", t, func() {
+		scs := NewShortcodes()
+		scs.Register("article", func(sc *Shortcode) (string, error) {
+			return fmt.Sprintf(`<a class="card" href="/article/%d">card</a>`, sc.Int("id", 0)), nil
+		})
+		scs.Register("note", func(sc *Shortcode) (string, error) {
+			return fmt.Sprintf(`<div class="note-%s">%s</div>`, html.EscapeString(sc.Get("type", "info")), sc.Inner), nil
+		})
+		Convey("展开独占一段的短代码", func() {
+			res, err := Render(KindArticle, "a\n\n{{< article id=12 >}}\n\nb", scs)
+			So(err, ShouldBeNil)
+			So(res.HTML, ShouldEqual, "<p>a</p>\n<a class=\"card\" href=\"/article/12\">card</a>\n<p>b</p>\n")
+		})
+		Convey("成对短代码渲染内部Markdown并过滤", func() {
+			res, err := Render(KindArticle, "{{< note type=\"warn\" >}}**注意**<script>x</script>{{< /note >}}", scs)
+			So(err, ShouldBeNil)
+			So(res.HTML, ShouldEqual, "<div class=\"note-warn\"><p><strong>注意</strong></p>\n</div>\n")
+		})
+		Convey("代码中的短代码与未注册的短代码保持原样", func() {
+			res, err := Render(KindArticle, "`{{< article id=1 >}}` {{< unknown x=1 >}}", scs)
+			So(err, ShouldBeNil)
+			So(res.HTML, ShouldEqual, "<p><code>{{&lt; article id=1 &gt;}}</code> {{&lt; unknown x=1 &gt;}}</p>\n")
+		})
+	})
+}
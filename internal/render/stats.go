@@ -0,0 +1,45 @@
+package render
+
+import (
+	"math"
+	"unicode"
+)
+
+// 阅读速度：中日韩文字按字计，其他语言按词计
+const (
+	cjkPerMinute  = 300
+	wordPerMinute = 200
+)
+
+// CountWords 统计字数，每个中日韩文字计为一个字，其余按空白与标点分隔的单词计
+func CountWords(s string) (cjk, words int) {
+	inWord := false
+	for _, r := range s {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' && inWord:
+			if !inWord {
+				words++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+	return
+}
+
+// ReadingTime 估算阅读时长（分钟），不足一分钟按一分钟计
+func ReadingTime(cjk, words int) int {
+	if cjk+words == 0 {
+		return 0
+	}
+	minutes := float64(cjk)/cjkPerMinute + float64(words)/wordPerMinute
+	return int(math.Max(1, math.Ceil(minutes)))
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
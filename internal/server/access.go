@@ -0,0 +1,69 @@
+package server
+
+import (
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/trace"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/core/router"
+	"net/http"
+	"time"
+)
+
+// newRequestIDWrapper 沿用请求头中的 X-Request-ID，没有或不合法时生成新的，
+// 写入响应头并保存到请求的 context 中，需最先执行
+func newRequestIDWrapper() router.WrapperFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		id := r.Header.Get(trace.Header)
+		if !trace.ValidID(id) {
+			id = trace.NewID()
+		}
+		w.Header().Set(trace.Header, id)
+		next(w, r.WithContext(trace.WithID(r.Context(), id)))
+	}
+}
+
+// requestID 当前请求的ID
+func requestID(ctx iris.Context) string {
+	return trace.ID(ctx.Request().Context())
+}
+
+// routeName 当前请求匹配的路由名称，未匹配时为 unmatched
+func routeName(ctx iris.Context) string {
+	if r := ctx.GetCurrentRoute(); r != nil {
+		return r.Name()
+	}
+	return "unmatched"
+}
+
+const accessLoggedKey = "accessLogged"
+
+// 以 JSON 行记录每个请求，用户ID由控制器解析登录状态后写入
+func newAccessLogHandler() iris.Handler {
+	return func(ctx iris.Context) {
+		start := time.Now()
+		ctx.Next()
+		if ctx.Values().GetBoolDefault(accessLoggedKey, false) {
+			return
+		}
+		ctx.Values().Set(accessLoggedKey, true)
+		status := ctx.GetStatusCode()
+		fields := trace.Fields{
+			"request_id": requestID(ctx),
+			"method":     ctx.Method(),
+			"path":       ctx.Path(),
+			"route":      routeName(ctx),
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"ip":         getClientIP(ctx)(),
+			"user_id":    ctx.Values().GetInt64Default(model.RequestUserKey, 0),
+		}
+		switch {
+		case status >= 500:
+			trace.Error("request", fields)
+		case status >= 400:
+			trace.Warn("request", fields)
+		default:
+			trace.Info("request", fields)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/trace"
+	"github.com/kataras/iris/v12"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	Convey("AccessLog", t, func() {
+		var buf bytes.Buffer
+		trace.SetOutput(&buf)
+		defer trace.SetOutput(nil)
+
+		e := iris.New()
+		e.WrapRouter(newRequestIDWrapper())
+		accessLog := newAccessLogHandler()
+		e.Use(accessLog)
+		e.OnAnyErrorCode(accessLog)
+		e.Get("/user", func(ctx iris.Context) {
+			ctx.Values().Set(model.RequestUserKey, int64(7))
+			ctx.WriteString(requestID(ctx))
+		}).Name = "user"
+		So(e.Build(), ShouldBeNil)
+		get := func(target string, header ...string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", target, nil)
+			for i := 0; i+1 < len(header); i += 2 {
+				req.Header.Set(header[i], header[i+1])
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec
+		}
+		lastLog := func() (m map[string]interface{}) {
+			So(json.Unmarshal(buf.Bytes(), &m), ShouldBeNil)
+			buf.Reset()
+			return
+		}
+
+		Convey("propagates a valid incoming id", func() {
+			rec := get("/user", trace.Header, "abc-123")
+			So(rec.Header().Get(trace.Header), ShouldEqual, "abc-123")
+			So(rec.Body.String(), ShouldEqual, "abc-123")
+			m := lastLog()
+			So(m["request_id"], ShouldEqual, "abc-123")
+			So(m["route"], ShouldEqual, "user")
+			So(m["status"], ShouldEqual, 200)
+			So(m["user_id"], ShouldEqual, 7)
+			So(m["level"], ShouldEqual, "info")
+			_, ok := m["latency_ms"]
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("replaces an invalid id", func() {
+			rec := get("/user", trace.Header, "bad id")
+			id := rec.Header().Get(trace.Header)
+			So(trace.ValidID(id), ShouldBeTrue)
+			So(id, ShouldNotEqual, "bad id")
+			So(lastLog()["request_id"], ShouldEqual, id)
+		})
+
+		Convey("logs unmatched routes once", func() {
+			rec := get("/missing")
+			So(rec.Code, ShouldEqual, 404)
+			So(bytes.Count(buf.Bytes(), []byte("\n")), ShouldEqual, 1)
+			m := lastLog()
+			So(m["route"], ShouldEqual, "unmatched")
+			So(m["level"], ShouldEqual, "warn")
+			So(m["user_id"], ShouldEqual, 0)
+		})
+	})
+}
@@ -5,12 +5,13 @@ import (
 	"ginana-blog/internal/config"
 	"ginana-blog/internal/model"
 	"ginana-blog/internal/service"
+	"ginana-blog/internal/trace"
 	"github.com/griffin702/ginana/library/ecode"
-	"github.com/griffin702/ginana/library/log"
 	"github.com/griffin702/service/tools"
 	"github.com/kataras/iris/v12"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func getPagination(svc service.Service) model.GetPagination {
@@ -70,11 +71,22 @@ func makeGlobalData(ctx iris.Context, svc service.Service) (err error) {
 		return
 	}
 	ctx.ViewData("hotArticles", hotArticles)
+	likedArticles, err := svc.GetLikedArticles(5)
+	if err != nil {
+		return
+	}
+	ctx.ViewData("likedArticles", likedArticles)
 	latestComments, err := svc.GetLatestComments(5)
 	if err != nil {
 		return
 	}
 	ctx.ViewData("latestComments", latestComments)
+	now := time.Now()
+	calendar, err := svc.GetCalendar(now.Year(), int(now.Month()))
+	if err != nil {
+		return
+	}
+	ctx.ViewData("calendar", calendar)
 	return
 }
 
@@ -104,20 +116,31 @@ func getConfigs(_ iris.Context) *config.Config {
 	return config.Global()
 }
 
-func jsonPlus(_ iris.Context) model.JsonPlus {
+func jsonPlus(ctx iris.Context) model.JsonPlus {
 	return func(data interface{}, msg interface{}) *model.JSON {
 		ec := ecode.Cause(msg)
-		return &model.JSON{
+		res := &model.JSON{
 			Code:    ec.Code(),
 			Message: ec.Message(),
 			Data:    data,
 		}
+		if res.Code != 0 {
+			res.RequestID = requestID(ctx)
+		}
+		return res
 	}
 }
 
 func errorHandler(ctx iris.Context, err error) {
 	jp := jsonPlus(ctx)(nil, err)
-	log.Errorf("%d, %s", jp.Code, jp.Message)
+	trace.Error("request error", trace.Fields{
+		"request_id": jp.RequestID,
+		"code":       jp.Code,
+		"message":    jp.Message,
+		"error":      err,
+		"route":      routeName(ctx),
+		"user_id":    ctx.Values().GetInt64Default(model.RequestUserKey, 0),
+	})
 	redirect := ctx.GetReferrer().Path
 	if redirect == "" {
 		redirect = "/"
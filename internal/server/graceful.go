@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/griffin702/ginana/library/log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 父进程交接的监听套接字在子进程中的文件描述符
+const listenFDEnv = "GINANA_LISTEN_FD"
+
+// Listen 优先使用父进程交接的监听套接字，inherited 为 true 时应在就绪后通知父进程退出
+func Listen(addr string) (l net.Listener, inherited bool, err error) {
+	if v := os.Getenv(listenFDEnv); v != "" {
+		_ = os.Unsetenv(listenFDEnv)
+		fd, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %v", listenFDEnv, err)
+		}
+		f := os.NewFile(uintptr(fd), "listener")
+		defer f.Close()
+		l, err = net.FileListener(f)
+		return l, true, err
+	}
+	l, err = net.Listen("tcp", addr)
+	return
+}
+
+// Handover 以相同的参数启动新版本的程序并交出监听套接字，新进程就绪后会通知本进程退出，
+// 期间监听不中断，用于升级程序
+func Handover(l net.Listener) (p *os.Process, err error) {
+	tl, ok := l.(*net.TCPListener)
+	if !ok {
+		return nil, errors.New("监听套接字不支持交接")
+	}
+	f, err := tl.File()
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), listenFDEnv+"=3")
+	cmd.ExtraFiles = []*os.File{f}
+	if err = cmd.Start(); err != nil {
+		return
+	}
+	return cmd.Process, nil
+}
+
+// Graceful 在同一个监听套接字上运行 http.Server，可以不中断监听地替换为新的 http.Server，
+// 旧的处理完进行中的请求后退出
+type Graceful struct {
+	l       net.Listener
+	mu      sync.Mutex
+	srv     *http.Server
+	cur     *connListener
+	closing bool
+	wg      sync.WaitGroup
+}
+
+func NewGraceful(l net.Listener, srv *http.Server) *Graceful {
+	g := &Graceful{l: l, srv: srv}
+	g.cur = g.start(srv)
+	return g
+}
+
+// Server 当前的 http.Server
+func (g *Graceful) Server() *http.Server {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.srv
+}
+
+// Serve 接受连接并分发给当前的 http.Server，Shutdown 后返回 nil
+func (g *Graceful) Serve() error {
+	var delay time.Duration
+	for {
+		conn, err := g.l.Accept()
+		if err != nil {
+			g.mu.Lock()
+			closing := g.closing
+			g.mu.Unlock()
+			if closing {
+				return nil
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if delay = delay * 2; delay == 0 {
+					delay = 5 * time.Millisecond
+				} else if delay > time.Second {
+					delay = time.Second
+				}
+				time.Sleep(delay)
+				continue
+			}
+			return err
+		}
+		delay = 0
+		g.dispatch(conn)
+	}
+}
+
+// dispatch 将连接交给当前的 http.Server，分发时恰好被替换则交给新的
+func (g *Graceful) dispatch(conn net.Conn) {
+	for {
+		g.mu.Lock()
+		cl := g.cur
+		g.mu.Unlock()
+		select {
+		case cl.conns <- conn:
+			return
+		case <-cl.done:
+			g.mu.Lock()
+			stopped := g.closing && g.cur == cl
+			g.mu.Unlock()
+			if stopped {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (g *Graceful) start(srv *http.Server) *connListener {
+	cl := &connListener{addr: g.l.Addr(), conns: make(chan net.Conn), done: make(chan struct{})}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := srv.Serve(cl); err != nil && err != http.ErrServerClosed {
+			log.Errorf("http serve error(%v)", err)
+		}
+	}()
+	return cl
+}
+
+// Replace 替换为新的 http.Server，新连接立即由其处理，旧的在 ctx 结束前处理完进行中的请求
+func (g *Graceful) Replace(ctx context.Context, srv *http.Server) error {
+	g.mu.Lock()
+	if g.closing {
+		g.mu.Unlock()
+		return http.ErrServerClosed
+	}
+	old := g.srv
+	g.srv, g.cur = srv, g.start(srv)
+	g.mu.Unlock()
+	return old.Shutdown(ctx)
+}
+
+// Shutdown 停止接受新连接，等待进行中的请求处理完毕，ctx 结束时强制关闭剩余连接
+func (g *Graceful) Shutdown(ctx context.Context) (err error) {
+	g.mu.Lock()
+	g.closing = true
+	srv := g.srv
+	g.mu.Unlock()
+	_ = g.l.Close()
+	if err = srv.Shutdown(ctx); err != nil {
+		_ = srv.Close()
+	}
+	g.wg.Wait()
+	return
+}
+
+// connListener 由 Graceful 分发连接的监听器，关闭时不影响真正的监听套接字
+type connListener struct {
+	addr  net.Addr
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, errors.New("listener closed")
+	}
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGraceful(t *testing.T) {
+	Convey("Graceful", t, func() {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		release := make(chan struct{})
+		handler := func(name string) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/slow" {
+					<-release
+				}
+				w.Write([]byte(name))
+			})
+		}
+		g := NewGraceful(l, &http.Server{Handler: handler("old")})
+		served := make(chan error, 1)
+		go func() { served <- g.Serve() }()
+		url := "http://" + l.Addr().String()
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		get := func(path string) (string, error) {
+			res, err := client.Get(url + path)
+			if err != nil {
+				return "", err
+			}
+			defer res.Body.Close()
+			b, err := ioutil.ReadAll(res.Body)
+			return string(b), err
+		}
+
+		body, err := get("/")
+		So(err, ShouldBeNil)
+		So(body, ShouldEqual, "old")
+
+		// 替换时进行中的请求由旧的 http.Server 处理完
+		slow := make(chan string, 1)
+		go func() {
+			body, _ := get("/slow")
+			slow <- body
+		}()
+		time.Sleep(50 * time.Millisecond)
+		replaced := make(chan error, 1)
+		go func() { replaced <- g.Replace(context.Background(), &http.Server{Handler: handler("new")}) }()
+		time.Sleep(50 * time.Millisecond)
+		body, err = get("/")
+		So(err, ShouldBeNil)
+		So(body, ShouldEqual, "new")
+		close(release)
+		So(<-slow, ShouldEqual, "old")
+		So(<-replaced, ShouldBeNil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		So(g.Shutdown(ctx), ShouldBeNil)
+		So(<-served, ShouldBeNil)
+		_, err = get("/")
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"crypto/subtle"
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/metrics"
+	"ginana-blog/internal/service"
+	"github.com/kataras/iris/v12"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 健康检查与指标地址，不计入访问统计，安装前也可访问
+var monitorPaths = []string{"/healthz", "/readyz", "/metrics"}
+
+func initMonitor(e *iris.Application, svc service.Service, cfg *config.Config) {
+	monitorGuard := newMonitorGuard(cfg)
+	e.Get("/healthz", monitorGuard, func(ctx iris.Context) {
+		ctx.JSON(iris.Map{"status": "ok"})
+	})
+	e.Get("/readyz", monitorGuard, func(ctx iris.Context) {
+		checks := svc.CheckReady()
+		status, result := "ok", make(map[string]string, len(checks))
+		for name, err := range checks {
+			result[name] = "ok"
+			if err != nil {
+				status, result[name] = "fail", err.Error()
+			}
+		}
+		if status != "ok" {
+			ctx.StatusCode(iris.StatusServiceUnavailable)
+		}
+		ctx.JSON(iris.Map{"status": status, "checks": result})
+	})
+	e.Get("/metrics", monitorGuard, func(ctx iris.Context) {
+		ctx.ContentType(metrics.ContentType)
+		_ = metrics.Write(ctx)
+	})
+}
+
+// newMonitorGuard 按 MonitorAllow 中的来源地址或 MonitorToken 放行。经反向代理转发的请求
+// 来源地址不可信，只能使用令牌
+func newMonitorGuard(cfg *config.Config) iris.Handler {
+	return func(ctx iris.Context) {
+		// 令牌只从 Authorization 头读取，不接受查询参数，避免出现在代理与访问日志中
+		auth := ctx.GetHeader("Authorization")
+		if token := cfg.MonitorToken; token != "" && strings.HasPrefix(auth, "Bearer ") {
+			if subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) == 1 {
+				ctx.Next()
+				return
+			}
+		}
+		if ctx.GetHeader("X-Forwarded-For") == "" && ctx.GetHeader("X-Real-IP") == "" &&
+			ipAllowed(ctx.Request().RemoteAddr, cfg.MonitorAllow) {
+			ctx.Next()
+			return
+		}
+		ctx.StatusCode(iris.StatusForbidden)
+		ctx.WriteString("forbidden")
+		ctx.StopExecution()
+	}
+}
+
+// ipAllowed 判断连接地址是否在空格分隔的地址或网段列表中
+func ipAllowed(remoteAddr, allow string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, v := range strings.Fields(allow) {
+		if strings.Contains(v, "/") {
+			if _, n, err := net.ParseCIDR(v); err == nil && n.Contains(ip) {
+				return true
+			}
+		} else if a := net.ParseIP(v); a != nil && a.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+const metricsRecordedKey = "metricsRecorded"
+
+// 按路由模板记录请求耗时，未匹配路由的请求记为 unmatched
+func newMetricsHandler() iris.Handler {
+	return func(ctx iris.Context) {
+		start := time.Now()
+		ctx.Next()
+		if ctx.Values().GetBoolDefault(metricsRecordedKey, false) {
+			return
+		}
+		ctx.Values().Set(metricsRecordedKey, true)
+		route := "unmatched"
+		if r := ctx.GetCurrentRoute(); r != nil {
+			route = r.Path()
+		}
+		metrics.HTTPRequestDuration.Since(start, ctx.Method(), route, strconv.Itoa(ctx.GetStatusCode()))
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"ginana-blog/internal/config"
+	"github.com/kataras/iris/v12"
+	. "github.com/smartystreets/goconvey/convey"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMonitorGuard(t *testing.T) {
+	Convey("MonitorGuard", t, func() {
+		cfg := &config.Config{MonitorAllow: "127.0.0.1 10.0.0.0/8", MonitorToken: "secret"}
+		e := iris.New()
+		e.OnAnyErrorCode(func(ctx iris.Context) {
+			ctx.WriteString("error page")
+		})
+		e.Get("/metrics", newMonitorGuard(cfg), func(ctx iris.Context) {
+			ctx.WriteString("ok")
+		})
+		So(e.Build(), ShouldBeNil)
+		get := func(remote, target string, header ...string) (int, string) {
+			req := httptest.NewRequest("GET", target, nil)
+			req.RemoteAddr = remote
+			for i := 0; i+1 < len(header); i += 2 {
+				req.Header.Set(header[i], header[i+1])
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec.Code, rec.Body.String()
+		}
+
+		code, body := get("127.0.0.1:1234", "/metrics")
+		So(code, ShouldEqual, 200)
+		So(body, ShouldEqual, "ok")
+		code, _ = get("10.1.2.3:1234", "/metrics")
+		So(code, ShouldEqual, 200)
+		code, body = get("192.168.1.1:1234", "/metrics")
+		So(code, ShouldEqual, 403)
+		So(body, ShouldEqual, "forbidden")
+		code, _ = get("127.0.0.1:1234", "/metrics", "X-Forwarded-For", "1.2.3.4")
+		So(code, ShouldEqual, 403)
+		code, _ = get("192.168.1.1:1234", "/metrics", "Authorization", "Bearer secret")
+		So(code, ShouldEqual, 200)
+		code, _ = get("192.168.1.1:1234", "/metrics", "Authorization", "Bearer wrong")
+		So(code, ShouldEqual, 403)
+		code, _ = get("192.168.1.1:1234", "/metrics", "Authorization", "secret")
+		So(code, ShouldEqual, 403)
+		code, _ = get("192.168.1.1:1234", "/metrics?token=secret")
+		So(code, ShouldEqual, 403)
+	})
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"ginana-blog/internal/service"
+	"github.com/kataras/iris/v12/core/router"
+	"net/http"
+	"strings"
+)
+
+// 不参与跳转匹配的路径
+var redirectSkipPrefix = []string{"/static/", "/admin", "/api/", "/public/", "/swagger/"}
+
+// 在路由匹配之前按跳转规则重定向，保留原请求的查询参数
+func newRedirectWrapper(svc service.Service) router.WrapperFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next(w, r)
+			return
+		}
+		path := r.URL.Path
+		for _, prefix := range redirectSkipPrefix {
+			if strings.HasPrefix(path, prefix) {
+				next(w, r)
+				return
+			}
+		}
+		target, code, ok := svc.MatchRedirect(path)
+		if !ok {
+			next(w, r)
+			return
+		}
+		if r.URL.RawQuery != "" && !strings.Contains(target, "?") {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, code)
+	}
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/metrics"
+	"github.com/griffin702/ginana/library/log"
+	"github.com/kataras/iris/v12/view"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// reloadableView 可以在运行中重新解析的模板引擎，新模板全部解析成功后才替换，
+// 渲染中的请求继续使用旧模板
+type reloadableView struct {
+	build func() *view.HTMLEngine
+	mu    sync.RWMutex
+	cur   *view.HTMLEngine
+	funcs map[string]interface{} // Iris 注册的模板函数，重建时补上
+}
+
+// 当前的模板引擎，未启用模板时为 nil
+var views *reloadableView
+
+func newReloadableView(build func() *view.HTMLEngine) *reloadableView {
+	return &reloadableView{build: build, cur: build(), funcs: make(map[string]interface{})}
+}
+
+func (v *reloadableView) engine() *view.HTMLEngine {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.cur
+}
+
+func (v *reloadableView) Load() error {
+	return v.engine().Load()
+}
+
+func (v *reloadableView) ExecuteWriter(w io.Writer, filename string, layout string, bindingData interface{}) error {
+	defer metrics.TemplateRenderDuration.Since(time.Now(), filename)
+	return v.engine().ExecuteWriter(w, filename, layout, bindingData)
+}
+
+func (v *reloadableView) Ext() string {
+	return v.engine().Ext()
+}
+
+func (v *reloadableView) AddFunc(funcName string, funcBody interface{}) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.funcs[funcName] = funcBody
+	v.cur.AddFunc(funcName, funcBody)
+}
+
+// reload 重新解析全部模板
+func (v *reloadableView) reload() error {
+	e := v.build()
+	v.mu.RLock()
+	for name, fn := range v.funcs {
+		e.AddFunc(name, fn)
+	}
+	v.mu.RUnlock()
+	if err := e.Load(); err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.cur = e
+	v.mu.Unlock()
+	return nil
+}
+
+// 当前生效的 [Server] 配置。重新载入时整体替换，不改动共享的 *config.Config，
+// 读取方不会看到改了一半的配置
+var serverConfig atomic.Value // *config.ServerConfig
+
+func currentServer(cfg *config.Config) *config.ServerConfig {
+	if s, ok := serverConfig.Load().(*config.ServerConfig); ok {
+		return s
+	}
+	return cfg.Server
+}
+
+// Reload 重新载入 http.toml 与模板：超时设置通过替换 http.Server 生效，旧的 http.Server
+// 在后台处理完进行中的请求；监听地址的变更需要交接重启后生效
+func Reload(g *Graceful, cfg *config.Config) (err error) {
+	next := new(config.Config)
+	if err = config.Load("http.toml", next); err != nil {
+		return
+	}
+	if next.Server == nil {
+		return errors.New("http.toml 缺少 [Server] 配置")
+	}
+	if problems := next.Server.Validate(); len(problems) > 0 {
+		return errors.New(strings.Join(problems, "；"))
+	}
+	if addr := currentServer(cfg).Addr; next.Server.Addr != addr {
+		log.Warnf("监听地址 %s 需要重启后生效，当前仍为 %s", next.Server.Addr, addr)
+		next.Server.Addr = addr
+	}
+	if views != nil {
+		if err = views.reload(); err != nil {
+			return
+		}
+	}
+	serverConfig.Store(next.Server)
+	srv := newHTTPServer(g.Server().Handler, cfg)
+	// 等待旧连接处理完毕不阻塞信号循环，之后的 SIGHUP 与 SIGTERM 可以立即处理
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout(cfg))
+		defer cancel()
+		if err := g.Replace(ctx, srv); err != nil && err != http.ErrServerClosed {
+			log.Errorf("reload drain error(%v)", err)
+		}
+	}()
+	return
+}
+
+// 未配置 shutdownTimeout 时等待进行中请求的时长
+const defShutdownTimeout = 15 * time.Second
+
+// ShutdownTimeout 优雅退出时等待进行中请求的最长时间
+func ShutdownTimeout(cfg *config.Config) time.Duration {
+	s := currentServer(cfg)
+	if s == nil || s.ShutdownTimeout <= 0 {
+		return defShutdownTimeout
+	}
+	return time.Duration(s.ShutdownTimeout)
+}
+
+func newHTTPServer(handler http.Handler, cfg *config.Config) *http.Server {
+	s := currentServer(cfg)
+	return &http.Server{
+		Addr:         s.Addr,
+		Handler:      handler,
+		ReadTimeout:  time.Duration(s.ReadTimeout),
+		WriteTimeout: time.Duration(s.WriteTimeout),
+		IdleTimeout:  time.Duration(s.IdleTimeout),
+	}
+}
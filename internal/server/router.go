@@ -3,8 +3,10 @@ package server
 import (
 	"ginana-blog/internal/config"
 	"ginana-blog/internal/controller/admin"
+	"ginana-blog/internal/controller/api"
 	"ginana-blog/internal/controller/front"
 	"ginana-blog/internal/controller/public"
+	"ginana-blog/internal/controller/setup"
 	"ginana-blog/internal/model"
 	"ginana-blog/internal/service"
 	"github.com/kataras/iris/v12"
@@ -23,8 +25,12 @@ func InitRouter(svc service.Service, cfg *config.Config, hm service.HelperMap, v
 		DisableSubdomainPersistence: true,
 	})
 
+	// 控制器中的服务带上当前请求的ID，数据库日志据此关联到请求
+	traced := func(ctx iris.Context) service.Service {
+		return svc.WithRequestID(requestID(ctx))
+	}
 	objects := []interface{}{
-		svc, session.Start, hm, valid, getSiteOptions(svc, cfg),
+		traced, session.Start, hm, valid, getSiteOptions(svc, cfg),
 		getClientIP, getPagination(svc), jsonPlus, getTools, getConfigs,
 	}
 
@@ -42,5 +48,12 @@ func InitRouter(svc service.Service, cfg *config.Config, hm service.HelperMap, v
 	publicParty.Router.Layout("layouts/public.html")
 	publicParty.Handle(new(public.CPublic))
 
+	setupParty := group.Party("/setup")
+	setupParty.Router.Layout("layouts/public.html")
+	setupParty.Handle(new(setup.CSetup))
+
+	apiParty := group.Party("/api")
+	apiParty.Handle(new(api.CApi))
+
 	return
 }
@@ -4,33 +4,23 @@ import (
 	"fmt"
 	"ginana-blog/internal/config"
 	"ginana-blog/internal/service"
-	"github.com/griffin702/ginana/library/conf/paladin"
 	"github.com/griffin702/ginana/library/log"
 	"github.com/griffin702/ginana/library/mdw"
 	"github.com/kataras/golog"
 	"github.com/kataras/iris/v12"
-	"github.com/kataras/iris/v12/middleware/logger"
 	"github.com/kataras/iris/v12/middleware/recover"
 	"net/http"
 	"strings"
-	"time"
 )
 
 func NewHttpServer(irisApp *iris.Application, cfg *config.Config) (h *http.Server, err error) {
-	if err = paladin.Get("http.toml").UnmarshalTOML(cfg); err != nil {
+	if err = config.Load("http.toml", cfg); err != nil {
 		return
 	}
 	if err = irisApp.Build(); err != nil {
 		log.Println(err.Error())
 	}
-	h = &http.Server{
-		Addr:         cfg.Server.Addr,
-		Handler:      irisApp,
-		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout),
-		WriteTimeout: time.Duration(cfg.Server.WriteTimeout),
-		IdleTimeout:  time.Duration(cfg.Server.IdleTimeout),
-	}
-	log.Printf("HTTP服务已启动 [ http://%s ]", cfg.Server.Addr)
+	h = newHTTPServer(irisApp, cfg)
 	return
 }
 
@@ -38,12 +28,14 @@ func newIris(svc service.Service, cfg *config.Config) (e *iris.Application) {
 	e = iris.New()
 	//e.Use(iris.Cache304(10 * time.Second))
 	golog.Install(log.GetLogger())
-	customLogger := logger.New(logger.Config{
-		Status: true, IP: true, Method: true, Path: true, Query: true,
-		//MessageHeaderKeys: []string{"User-Agent"},
-	})
-	e.Use(customLogger, recover.New())
-	e.OnAnyErrorCode(customLogger)
+	accessLog := newAccessLogHandler()
+	stat := newStatHandler(svc)
+	requestMetrics := newMetricsHandler()
+	e.WrapRouter(newRedirectWrapper(svc))
+	e.WrapRouter(newSetupWrapper(svc, cfg))
+	e.WrapRouter(newRequestIDWrapper())
+	e.Use(requestMetrics, accessLog, recover.New(), stat)
+	e.OnAnyErrorCode(requestMetrics, accessLog, stat)
 	e.Logger().SetLevel(cfg.IrisLogLevel)
 	initTemplate(e, svc, cfg)
 	initStaticDir(e, cfg)
@@ -56,6 +48,7 @@ func newIris(svc service.Service, cfg *config.Config) (e *iris.Application) {
 		ctx.Gzip(cfg.EnableGzip)
 		ctx.Next()
 	})
+	initMonitor(e, svc, cfg)
 	return
 }
 
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"ginana-blog/internal/config"
+	"ginana-blog/internal/service"
+	"github.com/kataras/iris/v12/core/router"
+	"net/http"
+	"strings"
+)
+
+// 未创建管理员时，除静态文件外的请求都跳转到安装向导
+func newSetupWrapper(svc service.Service, cfg *config.Config) router.WrapperFunc {
+	allow := append([]string{"/setup", "/favicon.ico"}, monitorPaths...)
+	for _, v := range strings.Fields(cfg.StaticDir) {
+		if i := strings.Index(v, ":"); i > 0 {
+			allow = append(allow, "/"+strings.Trim(v[:i], "/")+"/")
+		}
+	}
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if !svc.NeedSetup() {
+			next(w, r)
+			return
+		}
+		for _, prefix := range allow {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next(w, r)
+				return
+			}
+		}
+		http.Redirect(w, r, "/setup", http.StatusSeeOther)
+	}
+}
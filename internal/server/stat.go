@@ -0,0 +1,48 @@
+package server
+
+import (
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/service"
+	"github.com/kataras/iris/v12"
+	"strings"
+)
+
+// 不计入访问统计的路径
+var statSkipPrefix = []string{"/static/", "/admin", "/api/", "/public/", "/swagger/", "/favicon.ico", "/feed.xml", "/sitemap.xml"}
+
+const statRecordedKey = "statRecorded"
+
+// 第一方访问统计，只记录前台页面的GET请求
+func newStatHandler(svc service.Service) iris.Handler {
+	return func(ctx iris.Context) {
+		ctx.Next()
+		if ctx.Method() != iris.MethodGet || ctx.Values().GetBoolDefault(statRecordedKey, false) {
+			return
+		}
+		ctx.Values().Set(statRecordedKey, true)
+		path := ctx.Path()
+		for _, prefix := range statSkipPrefix {
+			if strings.HasPrefix(path, prefix) {
+				return
+			}
+		}
+		for _, p := range monitorPaths {
+			if path == p {
+				return
+			}
+		}
+		hit := &model.StatHit{
+			Path:      path,
+			Referrer:  ctx.GetHeader("Referer"),
+			Host:      ctx.Host(),
+			IP:        getClientIP(ctx)(),
+			UserAgent: ctx.GetHeader("User-Agent"),
+			Status:    ctx.GetStatusCode(),
+			ArticleID: ctx.Values().GetInt64Default(model.StatArticleKey, 0),
+		}
+		if ids, ok := ctx.Values().Get(model.StatTagsKey).([]int64); ok {
+			hit.TagIDs = ids
+		}
+		svc.RecordHit(hit)
+	}
+}
@@ -1,10 +1,13 @@
 package server
 
 import (
+	"fmt"
 	"ginana-blog/internal/config"
+	"ginana-blog/internal/render"
 	"ginana-blog/internal/service"
 	"github.com/griffin702/service/tools"
 	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/view"
 	"html/template"
 	"strings"
 	"time"
@@ -14,12 +17,18 @@ func initTemplate(e *iris.Application, svc service.Service, cfg *config.Config)
 	if !cfg.EnableTemplate {
 		return
 	}
-	tmpl := iris.HTML(cfg.ViewsPath, ".html").
-		Reload(cfg.ReloadTemplate)
-	tmpl.AddFunc("date", dateFormat)
-	tmpl.AddFunc("str2html", str2html)
-	tmpl.AddFunc("permission", permission(svc))
-	e.RegisterView(tmpl)
+	views = newReloadableView(func() *view.HTMLEngine {
+		tmpl := iris.HTML(cfg.ViewsPath, ".html").
+			Reload(cfg.ReloadTemplate)
+		tmpl.AddFunc("date", dateFormat)
+		tmpl.AddFunc("str2html", str2html)
+		tmpl.AddFunc("sanitize", sanitize)
+		tmpl.AddFunc("permission", permission(svc))
+		tmpl.AddFunc("dict", dict)
+		tmpl.AddFunc("add", add)
+		return tmpl
+	})
+	e.RegisterView(views)
 	return
 }
 
@@ -28,10 +37,36 @@ func dateFormat(t time.Time, format string) (template.HTML, error) {
 	return template.HTML(tools.New().TimeFormat(t, format)), nil
 }
 
+// 仅用于程序生成的可信HTML（分页、标签链接等），用户内容请使用sanitize
 func str2html(str string) (template.HTML, error) {
 	return template.HTML(str), nil
 }
 
+// 按内容类型过滤后输出HTML，kind: article, mood, comment
+func sanitize(kind, str string) (template.HTML, error) {
+	return render.SafeHTML(kind, str), nil
+}
+
+// 组装键值对，用于向子模板传递多个参数
+func dict(values ...interface{}) (map[string]interface{}, error) {
+	if len(values)%2 != 0 {
+		return nil, fmt.Errorf("dict: odd number of arguments")
+	}
+	m := make(map[string]interface{}, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		key, ok := values[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: key must be string")
+		}
+		m[key] = values[i+1]
+	}
+	return m, nil
+}
+
+func add(a, b int) int {
+	return a + b
+}
+
 func permission(svc service.Service) func(int64, string, string) bool {
 	return func(userId int64, router, method string) (isAuth bool) {
 		return svc.CheckPermission(userId, router, strings.ToUpper(method))
@@ -24,16 +24,26 @@ func NewHelperMap() (hm HelperMap, err error) {
 			1009: "该帐号未激活",
 			1010: "2次输入的密码不一致",
 			1011: "新密码不允许为空",
+			1012: "尝试次数过多，请稍后再试",
 		},
 		CacheKey: map[int]string{
-			1: "user",
-			2: "role",
-			3: "siteOptions",
-			4: "latestArticles",
-			5: "hotArticles",
-			6: "latestComments",
-			7: "allLinks",
-			8: "captchaCode",
+			1:  "user",
+			2:  "role",
+			3:  "siteOptions",
+			4:  "latestArticles",
+			5:  "hotArticles",
+			6:  "latestComments",
+			7:  "allLinks",
+			8:  "captchaCode",
+			9:  "articleRender",
+			10: "relatedArticles",
+			11: "relatedVersion",
+			12: "likedArticles",
+			13: "categories",
+			14: "archiveDays",
+			15: "redirects",
+			16: "unlockAttempts",
+			17: "renderVersion",
 		},
 	}
 	return
@@ -46,6 +46,14 @@ func (s *service) GetPhotos(p *model.Pager, albumId int64) (res *model.Photos, e
 	return
 }
 
+func (s *service) GetPhoto(id int64) (photo *model.Photo, err error) {
+	photo = new(model.Photo)
+	if err = s.db.Find(photo, "id = ?", id).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	return
+}
+
 func (s *service) CreateAlbum(req *model.CreateAlbumReq) (album *model.Album, err error) {
 	album = new(model.Album)
 	album.Name = req.Name
@@ -55,6 +63,7 @@ func (s *service) CreateAlbum(req *model.CreateAlbumReq) (album *model.Album, er
 	if err = s.db.Create(album).Error; err != nil {
 		return nil, s.hm.GetMessage(1002, err)
 	}
+	s.bumpRenderVersion()
 	return
 }
 
@@ -74,6 +83,7 @@ func (s *service) UpdateAlbum(req *model.UpdateAlbumReq) (album *model.Album, er
 	if err = s.db.Model(album).Update(m).Error; err != nil {
 		return nil, s.hm.GetMessage(1003, err)
 	}
+	s.bumpRenderVersion()
 	return
 }
 
@@ -82,6 +92,7 @@ func (s *service) SetAlbumStatus(id int64, hidden bool) (err error) {
 	if err = s.db.Model(album).Where("id = ?", id).Update("hidden", hidden).Error; err != nil {
 		return s.hm.GetMessage(1003)
 	}
+	s.bumpRenderVersion()
 	return
 }
 
@@ -101,6 +112,7 @@ func (s *service) CreatePhoto(req *model.CreatePhotoReq) (photo *model.Photo, er
 	if err = s.db.Create(photo).Error; err != nil {
 		return nil, s.hm.GetMessage(1002, err)
 	}
+	s.bumpRenderVersion()
 	return
 }
 
@@ -119,6 +131,7 @@ func (s *service) UpdatePhoto(req *model.UpdatePhotoReq) (photo *model.Photo, er
 	if err = s.db.Model(photo).Update(m).Error; err != nil {
 		return nil, s.hm.GetMessage(1003, err)
 	}
+	s.bumpRenderVersion()
 	return
 }
 
@@ -142,6 +155,7 @@ func (s *service) DeleteAlbum(id int64) (err error) {
 		return s.hm.GetMessage(1004, err)
 	}
 	tx.Commit()
+	s.bumpRenderVersion()
 	for url, small := range photoMap {
 		s.deletePhotoSource(url, small)
 	}
@@ -154,6 +168,7 @@ func (s *service) DeletePhoto(id int64) (err error) {
 		return s.hm.GetMessage(1004, err)
 	}
 	s.deletePhotoSource(photo.Url, photo.ChangetoSmall())
+	s.bumpRenderVersion()
 	return
 }
 
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"ginana-blog/internal/model"
+	"time"
+)
+
+// GetArchives 按年、月归档的已发布文章数
+func (s *service) GetArchives() (years []*model.ArchiveYear, err error) {
+	days, err := s.getArchiveDays()
+	if err != nil {
+		return
+	}
+	return model.NewArchiveYears(days), nil
+}
+
+// GetCalendar 指定月份的日历，标记有文章发布的日期
+func (s *service) GetCalendar(year, month int) (calendar *model.Calendar, err error) {
+	days, err := s.getArchiveDays()
+	if err != nil {
+		return
+	}
+	start, end := (&model.ArchiveMonth{Year: year, Month: month}).Range()
+	counts := make(map[int]int64)
+	for _, d := range days {
+		t, e := time.ParseInLocation("2006-01-02", d.Date, time.Local)
+		if e == nil && !t.Before(start) && t.Before(end) {
+			counts[t.Day()] = d.Count
+		}
+	}
+	return model.NewCalendar(year, month, counts, time.Now()), nil
+}
+
+// getArchiveDays 已发布文章的每日数量，按日期倒序缓存，文章变更时清除
+func (s *service) getArchiveDays() (days []*model.ArchiveDay, err error) {
+	key := s.hm.GetCacheKey(14)
+	if err = s.mc.Get(key, &days); err != nil {
+		if err = s.db.Model(&model.Article{}).
+			Select("DATE_FORMAT(created_at, '%Y-%m-%d') as date, count(*) as count").
+			Where("status = 0").Group("date").Order("date desc").Scan(&days).Error; err != nil {
+			return nil, s.hm.GetMessage(1001, err)
+		}
+		if err = s.mc.Set(key, &days); err != nil {
+			return nil, s.hm.GetMessage(1002, err)
+		}
+	}
+	return
+}
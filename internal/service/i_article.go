@@ -1,12 +1,15 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
 	"ginana-blog/internal/model"
+	"ginana-blog/internal/render"
 	"github.com/jinzhu/gorm"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 )
 
 func (s *service) GetArticles(p *model.Pager, prs ...model.ArticleQueryParam) (res *model.Articles, err error) {
@@ -31,15 +34,13 @@ func (s *service) GetArticles(p *model.Pager, prs ...model.ArticleQueryParam) (r
 			s.db.Model(&model.User{}).Select("id").
 				Where("nickname like ?", "%"+pr.Keyword+"%").Pluck("id", &userIdList)
 			query = query.Having("user_id in (?)", userIdList)
-		case "tag":
-			var tagList []*model.Tag
-			s.db.Model(&tagList).Preload("Articles").Find(&tagList, "name like ?", "%"+pr.Keyword+"%")
-			var idList []int64
-			for _, tag := range tagList {
-				for _, art := range tag.Articles {
-					idList = append(idList, art.ID)
-				}
-			}
+		case "tag": // 同时匹配标签名与同义词
+			keyword := "%" + model.TagKey(pr.Keyword) + "%"
+			var tagIdList, aliasIdList, idList []int64
+			s.db.Model(&model.Tag{}).Where("lower(name) like ?", keyword).Pluck("id", &tagIdList)
+			s.db.Model(&model.TagAlias{}).Where("name like ?", keyword).Pluck("tag_id", &aliasIdList)
+			s.db.Model(&model.ArticleTags{}).Where("tag_id in (?)", append(tagIdList, aliasIdList...)).
+				Pluck("article_id", &idList)
 			query = query.Having("id in (?)", idList)
 		}
 	}
@@ -50,12 +51,32 @@ func (s *service) GetArticles(p *model.Pager, prs ...model.ArticleQueryParam) (r
 		whereStr := fmt.Sprintf("%s.tag_id = ?", bn)
 		query = query.Joins(joinStr).Where(whereStr, pr.TagID)
 	}
+	if pr.Public {
+		query = query.Where("visibility = ?", model.VisibilityPublic)
+	}
+	if len(pr.CategoryIDs) > 0 {
+		query = query.Where("category_id in (?)", pr.CategoryIDs)
+	}
+	if !pr.StartAt.IsZero() {
+		query = query.Where("created_at >= ?", pr.StartAt)
+	}
+	if !pr.EndAt.IsZero() {
+		query = query.Where("created_at < ?", pr.EndAt)
+	}
 	query = query.Where("status = ?", pr.Status)
 	query.Count(&p.AllCount)
 	query = query.Order(pr.Order).Preload("User").Preload("Tags")
 	if err = query.Limit(p.PageSize).Offset((p.Page - 1) * p.PageSize).Find(&res.List).Error; err != nil {
 		return nil, s.hm.GetMessage(1001, err)
 	}
+	for _, article := range res.List {
+		if err = s.RenderArticle(article); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.setArticleCategory(res.List...); err != nil {
+		return nil, err
+	}
 	res.Status = pr.Status
 	res.Search = pr.Search
 	res.Keyword = pr.Keyword
@@ -70,44 +91,62 @@ func (s *service) GetArticle(id int64) (article *model.Article, err error) {
 		Find(article).Error; err != nil {
 		return nil, s.hm.GetMessage(1001, err)
 	}
+	if err = s.setArticleNeighbours(article); err != nil {
+		return nil, err
+	}
+	if err = s.RenderArticle(article); err != nil {
+		return nil, err
+	}
+	s.fillViews(article)
+	if err = s.setArticleSeries(article); err != nil {
+		return nil, err
+	}
+	if err = s.setArticleCategory(article); err != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// setArticleNeighbours 上一篇与下一篇，仅在已发布的公开文章中查找，且只取生成链接所需的字段
+func (s *service) setArticleNeighbours(article *model.Article) (err error) {
+	query := s.db.Select("id, title, color, urlname, urltype").
+		Where("status = 0 and visibility = ?", model.VisibilityPublic)
 	var prev, next model.Article
-	err = s.db.Model(&prev).Last(&prev, "id < ?", id).Error
+	err = query.Last(&prev, "id < ?", article.ID).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
-		return nil, s.hm.GetMessage(1001, err)
+		return s.hm.GetMessage(1001, err)
 	}
 	if err == nil {
 		article.Prev = &prev
 	}
-	err = s.db.Model(&next).First(&next, "id > ?", id).Error
+	err = query.First(&next, "id > ?", article.ID).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
-		return nil, s.hm.GetMessage(1001, err)
+		return s.hm.GetMessage(1001, err)
 	}
 	if err == nil {
 		article.Next = &next
 	}
-	return article, nil
+	return nil
 }
 
 func (s *service) GetArticleByUrlName(urlName string) (article *model.Article, err error) {
 	article = new(model.Article)
-	if err = s.db.Model(article).Preload("User").Preload("Tags").
+	if err = s.db.Model(article).Preload("User").Preload("Tags").Order("urltype desc, id asc").
 		Find(article, "urlname = ?", urlName).Error; err != nil {
 		return nil, s.hm.GetMessage(1001, err)
 	}
-	var prev, next model.Article
-	err = s.db.Model(&prev).Last(&prev, "id < ?", article.ID).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return nil, s.hm.GetMessage(1001, err)
+	if err = s.setArticleNeighbours(article); err != nil {
+		return nil, err
 	}
-	if err == nil {
-		article.Prev = &prev
+	if err = s.RenderArticle(article); err != nil {
+		return nil, err
 	}
-	err = s.db.Model(&next).First(&next, "id > ?", article.ID).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return nil, s.hm.GetMessage(1001, err)
+	s.fillViews(article)
+	if err = s.setArticleSeries(article); err != nil {
+		return nil, err
 	}
-	if err == nil {
-		article.Next = &next
+	if err = s.setArticleCategory(article); err != nil {
+		return nil, err
 	}
 	return article, nil
 }
@@ -116,7 +155,8 @@ func (s *service) GetLatestArticles(limit int) (articles []*model.Article, err e
 	key := s.hm.GetCacheKey(4)
 	err = s.mc.Get(key, &articles)
 	if err != nil {
-		if err = s.db.Model(&articles).Order("created_at desc").Limit(limit).Find(&articles).Error; err != nil {
+		if err = s.db.Model(&articles).Where("visibility = ?", model.VisibilityPublic).
+			Order("created_at desc").Limit(limit).Find(&articles).Error; err != nil {
 			return nil, s.hm.GetMessage(1001, err)
 		}
 		if err = s.mc.Set(key, &articles); err != nil {
@@ -130,7 +170,8 @@ func (s *service) GetHotArticles(limit int) (articles []*model.Article, err erro
 	key := s.hm.GetCacheKey(5)
 	err = s.mc.Get(key, &articles)
 	if err != nil {
-		if err = s.db.Model(&articles).Order("views desc").Limit(limit).Find(&articles).Error; err != nil {
+		if err = s.db.Model(&articles).Where("visibility = ?", model.VisibilityPublic).
+			Order("views desc").Limit(limit).Find(&articles).Error; err != nil {
 			return nil, s.hm.GetMessage(1001, err)
 		}
 		if err = s.mc.Set(key, &articles); err != nil {
@@ -150,29 +191,32 @@ func (s *service) CreateArticle(req *model.ArticleReq) (article *model.Article,
 	article = new(model.Article)
 	article.Title = req.Title
 	article.Color = req.Color
-	article.Urlname = req.Urlname
+	if article.Urlname, err = s.ArticleSlug(req.Title, req.Urlname, req.Urltype, 0); err != nil {
+		return nil, err
+	}
 	article.Urltype = req.Urltype
 	article.Istop = req.Istop
 	article.Status = req.Status
-	article.Content = req.ContentMarkdownDoc
+	article.Content = render.Markdown(render.KindArticle, req.ContentMarkdownDoc)
 	article.Cover = req.Cover
 	article.ComeFrom = req.ComeFrom
 	article.UserID = req.UserID
-	tags := strings.Split(req.Tags, ",")
-	for _, name := range tags {
-		tag, err := s.GetTagByName(name)
-		if err == gorm.ErrRecordNotFound {
-			tag = new(model.Tag)
-			tag.Name = name
-			err = nil
-		} else if err != nil {
-			return nil, s.hm.GetMessage(500, err)
-		}
-		article.Tags = append(article.Tags, tag)
+	article.CategoryID = req.CategoryID
+	if err = s.setArticleVisibility(article, req); err != nil {
+		return nil, err
+	}
+	if err = s.setArticleStats(article); err != nil {
+		return nil, err
+	}
+	if article.Tags, err = s.articleTags(req.Tags); err != nil {
+		return nil, err
 	}
 	if err = s.db.Create(article).Error; err != nil {
 		return nil, s.hm.GetMessage(1002, err)
 	}
+	if s.clearRedirectSource(s.db, article.Link()) == nil {
+		s.mc.Delete(s.hm.GetCacheKey(15))
+	}
 	s.deleteArticleCache()
 	return
 }
@@ -182,15 +226,25 @@ func (s *service) UpdateArticle(req *model.ArticleReq) (article *model.Article,
 	if err = s.db.Find(article, "id = ?", req.ID).Error; err != nil {
 		return nil, s.hm.GetMessage(1001, err)
 	}
+	oldLink := article.Link()
 	article.Title = req.Title
 	article.Color = req.Color
-	article.Urlname = req.Urlname
+	if article.Urlname, err = s.ArticleSlug(req.Title, req.Urlname, req.Urltype, article.ID); err != nil {
+		return nil, err
+	}
 	article.Urltype = req.Urltype
 	article.Istop = req.Istop
 	article.Status = req.Status
-	article.Content = req.ContentMarkdownDoc
+	article.Content = render.Markdown(render.KindArticle, req.ContentMarkdownDoc)
 	article.Cover = req.Cover
 	article.ComeFrom = req.ComeFrom
+	article.CategoryID = req.CategoryID
+	if err = s.setArticleVisibility(article, req); err != nil {
+		return nil, err
+	}
+	if err = s.setArticleStats(article); err != nil {
+		return nil, err
+	}
 	m, err := s.tool.StructToMap(article)
 	if err != nil {
 		return nil, s.hm.GetMessage(500, err)
@@ -200,23 +254,20 @@ func (s *service) UpdateArticle(req *model.ArticleReq) (article *model.Article,
 		tx.Rollback()
 		return nil, s.hm.GetMessage(1003, err)
 	}
-	tags := strings.Split(req.Tags, ",")
-	for _, name := range tags {
-		tag, err := s.GetTagByName(name)
-		if err == gorm.ErrRecordNotFound {
-			tag = new(model.Tag)
-			tag.Name = name
-			err = nil
-		} else if err != nil {
-			return nil, s.hm.GetMessage(500, err)
-		}
-		article.Tags = append(article.Tags, tag)
+	if article.Tags, err = s.articleTags(req.Tags); err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 	if err = tx.Model(article).Update(article).Error; err != nil {
 		tx.Rollback()
 		return nil, s.hm.GetMessage(1003, err)
 	}
+	if err = s.saveArticleRedirect(tx, oldLink, article.Link()); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 	tx.Commit()
+	s.mc.Delete(s.hm.GetCacheKey(15))
 	s.deleteArticleCache()
 	return
 }
@@ -226,6 +277,8 @@ func (s *service) DeleteArticle(id int64) (err error) {
 	if err = s.db.Delete(article, "id = ?", id).Error; err != nil {
 		return s.hm.GetMessage(1004, err)
 	}
+	s.db.Delete(&model.SeriesArticle{}, "article_id = ?", id)
+	s.deleteArticleCache()
 	return
 }
 
@@ -242,6 +295,7 @@ func (s *service) BatchArticle(req *model.ArticleListReq) (err error) {
 		if err = s.db.Model(article).Where("id in (?)", req.IDs).Delete(article).Error; err != nil {
 			return s.hm.GetMessage(1004, err)
 		}
+		s.db.Delete(&model.SeriesArticle{}, "article_id in (?)", req.IDs)
 	}
 	if err != nil {
 		return s.hm.GetMessage(1003, err)
@@ -265,7 +319,69 @@ func (s *service) PushBaiDu(url string) (string, error) {
 	return string(body), nil
 }
 
+// RenderArticle 渲染文章正文，结果按文章版本（更新时间）与短代码引用内容的版本缓存
+func (s *service) RenderArticle(article *model.Article) (err error) {
+	key := s.hm.GetCacheKey(9, fmt.Sprintf("%d_%d_%d", article.ID, article.UpdatedAt.UnixNano(), s.renderVersion()))
+	res := new(render.Result)
+	if err = s.mc.Get(key, res); err != nil {
+		if res, err = render.Render(render.KindArticle, article.Content, s.scs); err != nil {
+			return s.hm.GetMessage(500, err)
+		}
+		if err = s.mc.Set(key, res); err != nil {
+			return s.hm.GetMessage(1002, err)
+		}
+	}
+	article.HTML = res.HTML
+	article.TOC = res.TOC
+	article.PlainText = res.Text
+	// 未执行过 reindex 的旧文章没有统计信息，只在本次展示时补齐，不在读取时写库
+	if article.Words == 0 && res.WordCount > 0 {
+		article.Words = int64(res.WordCount)
+		article.ReadTime = int64(res.ReadingTime)
+		article.Headings = res.Outline
+		return
+	}
+	article.LoadOutline()
+	return
+}
+
+// 保存前计算字数、阅读时长与标题大纲
+func (s *service) setArticleStats(article *model.Article) (err error) {
+	res, err := render.Render(render.KindArticle, article.Content, s.scs)
+	if err != nil {
+		return s.hm.GetMessage(500, err)
+	}
+	outline, err := json.Marshal(res.Outline)
+	if err != nil {
+		return s.hm.GetMessage(500, err)
+	}
+	article.Words = int64(res.WordCount)
+	article.ReadTime = int64(res.ReadingTime)
+	article.Outline = string(outline)
+	return
+}
+
+// renderVersion 短代码引用的相册、照片与文章的版本，版本不存在时以当前时间新建
+func (s *service) renderVersion() (version int64) {
+	key := s.hm.GetCacheKey(17)
+	if err := s.mc.Get(key, &version); err != nil {
+		version = time.Now().UnixNano()
+		_ = s.mc.Set(key, &version)
+	}
+	return
+}
+
+// bumpRenderVersion 相册、照片或文章变更后使全部文章的渲染缓存失效
+func (s *service) bumpRenderVersion() {
+	s.mc.Delete(s.hm.GetCacheKey(17))
+}
+
 func (s *service) deleteArticleCache() {
+	s.bumpRenderVersion()
 	s.mc.Delete(s.hm.GetCacheKey(4))
 	s.mc.Delete(s.hm.GetCacheKey(5))
+	s.mc.Delete(s.hm.GetCacheKey(11))
+	s.mc.Delete(s.hm.GetCacheKey(12))
+	s.mc.Delete(s.hm.GetCacheKey(13))
+	s.mc.Delete(s.hm.GetCacheKey(14))
 }
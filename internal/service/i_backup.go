@@ -0,0 +1,190 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"ginana-blog/internal/model"
+	"github.com/jinzhu/gorm"
+	"reflect"
+	"strings"
+)
+
+// 多对多关联表
+type joinTable struct {
+	name    string
+	columns []string
+}
+
+// joinTables 模型上声明的多对多关联表，同一张表只出现一次
+func joinTables(db *gorm.DB) (list []*joinTable) {
+	seen := make(map[string]bool)
+	for _, m := range model.Tables {
+		for _, f := range db.NewScope(m).GetModelStruct().StructFields {
+			r := f.Relationship
+			if r == nil || r.Kind != "many_to_many" || r.JoinTableHandler == nil {
+				continue
+			}
+			name := r.JoinTableHandler.Table(db)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			columns := append(append([]string{}, r.ForeignDBNames...), r.AssociationForeignDBNames...)
+			list = append(list, &joinTable{name: name, columns: columns})
+		}
+	}
+	return
+}
+
+// BackupTables 读取全部数据表，包括已软删除的记录与多对多关联表
+func (s *service) BackupTables() (tables []*model.BackupTable, err error) {
+	for _, m := range model.Tables {
+		scope := s.db.NewScope(m)
+		list := reflect.New(reflect.SliceOf(reflect.TypeOf(m)))
+		if err = s.db.Unscoped().Order(scope.PrimaryKey() + " asc").Find(list.Interface()).Error; err != nil {
+			return nil, s.hm.GetMessage(1001, err)
+		}
+		table := &model.BackupTable{Name: scope.TableName(), Rows: []map[string]interface{}{}}
+		for i := 0; i < list.Elem().Len(); i++ {
+			row := make(map[string]interface{})
+			for _, f := range s.db.NewScope(list.Elem().Index(i).Interface()).Fields() {
+				if f.IsNormal && !f.IsIgnored {
+					row[f.DBName] = f.Field.Interface()
+				}
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		tables = append(tables, table)
+	}
+	for _, jt := range joinTables(s.db) {
+		table := &model.BackupTable{Name: jt.name, Rows: []map[string]interface{}{}}
+		rows, err := s.db.Table(jt.name).Select(strings.Join(jt.columns, ", ")).Rows()
+		if err != nil {
+			return nil, s.hm.GetMessage(1001, err)
+		}
+		for rows.Next() {
+			values := make([]int64, len(jt.columns))
+			dest := make([]interface{}, len(values))
+			for i := range values {
+				dest[i] = &values[i]
+			}
+			if err = rows.Scan(dest...); err != nil {
+				rows.Close()
+				return nil, s.hm.GetMessage(1001, err)
+			}
+			row := make(map[string]interface{})
+			for i, c := range jt.columns {
+				row[c] = values[i]
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		rows.Close()
+		tables = append(tables, table)
+	}
+	return
+}
+
+// RestoreTables 在同一事务中清空全部数据表并写入备份，备份中没有的表被清空，
+// 当前结构中不存在的表与列被忽略。完成后清空缓存并重新载入权限规则
+func (s *service) RestoreTables(tables []*model.BackupTable) (err error) {
+	data := make(map[string]*model.BackupTable)
+	for _, t := range tables {
+		data[t.Name] = t
+	}
+	tx := s.db.Begin()
+	if err = s.withDB(tx).restoreTables(data); err != nil {
+		tx.Rollback()
+		return s.hm.GetMessage(1002, err)
+	}
+	if err = tx.Commit().Error; err != nil {
+		return s.hm.GetMessage(1002, err)
+	}
+	_ = s.mc.FlushAll()
+	if s.ef != nil {
+		_ = s.ef.LoadPolicy()
+	}
+	return
+}
+
+func (s *service) restoreTables(data map[string]*model.BackupTable) (err error) {
+	for _, m := range model.Tables {
+		scope := s.db.NewScope(m)
+		if err = s.db.Exec("DELETE FROM " + scope.QuotedTableName()).Error; err != nil {
+			return
+		}
+		table, ok := data[scope.TableName()]
+		if !ok {
+			continue
+		}
+		for i, row := range table.Rows {
+			if err = s.restoreRow(reflect.TypeOf(m).Elem(), row); err != nil {
+				return fmt.Errorf("%s 第%d行：%v", table.Name, i+1, err)
+			}
+		}
+	}
+	for _, jt := range joinTables(s.db) {
+		scope := s.db.NewScope(nil)
+		if err = s.db.Exec("DELETE FROM " + scope.Quote(jt.name)).Error; err != nil {
+			return
+		}
+		table, ok := data[jt.name]
+		if !ok {
+			continue
+		}
+		for i, row := range table.Rows {
+			values := make([]interface{}, len(jt.columns))
+			for j, c := range jt.columns {
+				if values[j], err = jsonValue(row[c], new(int64)); err != nil {
+					return fmt.Errorf("%s 第%d行：%v", jt.name, i+1, err)
+				}
+			}
+			if err = s.insert(scope.Quote(jt.name), jt.columns, values); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// restoreRow 按模型字段类型还原一行数据并原样写入，不触发默认值与关联保存
+func (s *service) restoreRow(typ reflect.Type, row map[string]interface{}) (err error) {
+	scope := s.db.NewScope(reflect.New(typ).Interface())
+	var columns []string
+	var values []interface{}
+	for _, f := range scope.Fields() {
+		if !f.IsNormal || f.IsIgnored {
+			continue
+		}
+		if v, ok := row[f.DBName]; ok {
+			if _, err = jsonValue(v, f.Field.Addr().Interface()); err != nil {
+				return fmt.Errorf("%s: %v", f.DBName, err)
+			}
+		}
+		columns = append(columns, f.DBName)
+		values = append(values, f.Field.Interface())
+	}
+	return s.insert(scope.QuotedTableName(), columns, values)
+}
+
+func (s *service) insert(table string, columns []string, values []interface{}) error {
+	scope := s.db.NewScope(nil)
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = scope.Quote(c)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",")
+	return s.db.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(quoted, ","), placeholders), values...).Error
+}
+
+// jsonValue 将备份中解码出的值转换为目标类型，dest 为指针
+func jsonValue(v interface{}, dest interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, dest); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(dest).Elem().Interface(), nil
+}
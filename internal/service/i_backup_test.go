@@ -0,0 +1,40 @@
+package service
+
+import (
+	"encoding/json"
+	"ginana-blog/internal/model"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestBackupTables(t *testing.T) {
+	requireDB(t)
+	Convey("BackupTables", t, func() {
+		tables, err := svc.BackupTables()
+		So(err, ShouldBeNil)
+		names := make(map[string]int)
+		for _, t := range tables {
+			names[t.Name] = len(t.Rows)
+		}
+		So(names, ShouldContainKey, "users")
+		So(names, ShouldContainKey, "user_roles")
+		So(names, ShouldContainKey, "article_tags")
+		So(names["users"], ShouldBeGreaterThan, 0)
+
+		Convey("经 JSON 往返后恢复，数据不变", func() {
+			b, err := json.Marshal(tables)
+			So(err, ShouldBeNil)
+			var restored []*model.BackupTable
+			So(json.Unmarshal(b, &restored), ShouldBeNil)
+			So(svc.RestoreTables(restored), ShouldBeNil)
+			again, err := svc.BackupTables()
+			So(err, ShouldBeNil)
+			for _, t := range again {
+				So(len(t.Rows), ShouldEqual, names[t.Name])
+			}
+			user, err := svc.GetUser(1)
+			So(err, ShouldBeNil)
+			So(user.Username, ShouldNotBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"fmt"
+	"ginana-blog/internal/model"
+	"regexp"
+	"strings"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// GetCategoryTree 分类树，扁平列表缓存，每次调用重新构建
+func (s *service) GetCategoryTree() (tree *model.CategoryTree, err error) {
+	key := s.hm.GetCacheKey(13)
+	var list []*model.Category
+	if err = s.mc.Get(key, &list); err != nil {
+		if err = s.db.Order("sort asc, id asc").Find(&list).Error; err != nil {
+			return nil, s.hm.GetMessage(1001, err)
+		}
+		var counts []struct {
+			CategoryID int64
+			Count      int64
+		}
+		if err = s.db.Model(&model.Article{}).Select("category_id, count(*) as count").
+			Where("status = 0 and category_id > 0").Group("category_id").Scan(&counts).Error; err != nil {
+			return nil, s.hm.GetMessage(1001, err)
+		}
+		countMap := make(map[int64]int64, len(counts))
+		for _, c := range counts {
+			countMap[c.CategoryID] = c.Count
+		}
+		for _, c := range list {
+			c.CountArticles = countMap[c.ID]
+		}
+		if err = s.mc.Set(key, &list); err != nil {
+			return nil, s.hm.GetMessage(1002, err)
+		}
+	}
+	return model.NewCategoryTree(list), nil
+}
+
+func (s *service) GetCategory(id int64) (category *model.Category, err error) {
+	category = new(model.Category)
+	if err = s.db.Find(category, "id = ?", id).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	return
+}
+
+func (s *service) GetCategoryBySlug(slug string) (category *model.Category, err error) {
+	category = new(model.Category)
+	if err = s.db.Find(category, "slug = ?", slug).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	return
+}
+
+func (s *service) CreateCategory(req *model.CreateCategoryReq) (category *model.Category, err error) {
+	category = new(model.Category)
+	category.ParentID = req.ParentID
+	category.Name = strings.TrimSpace(req.Name)
+	category.Description = req.Description
+	category.Cover = req.Cover
+	category.Sort = req.Sort
+	if category.Slug, err = s.categorySlug(category.ID, req.Slug, category.Name); err != nil {
+		return nil, err
+	}
+	if err = s.checkCategoryParent(0, category.ParentID); err != nil {
+		return nil, err
+	}
+	if err = s.db.Create(category).Error; err != nil {
+		return nil, s.hm.GetMessage(1002, err)
+	}
+	s.mc.Delete(s.hm.GetCacheKey(13))
+	return
+}
+
+func (s *service) UpdateCategory(req *model.UpdateCategoryReq) (category *model.Category, err error) {
+	category, err = s.GetCategory(req.ID)
+	if err != nil {
+		return
+	}
+	category.ParentID = req.ParentID
+	category.Name = strings.TrimSpace(req.Name)
+	category.Description = req.Description
+	category.Cover = req.Cover
+	category.Sort = req.Sort
+	if category.Slug, err = s.categorySlug(category.ID, req.Slug, category.Name); err != nil {
+		return nil, err
+	}
+	if err = s.checkCategoryParent(category.ID, category.ParentID); err != nil {
+		return nil, err
+	}
+	if err = s.db.Model(category).Updates(map[string]interface{}{
+		"parent_id":   category.ParentID,
+		"name":        category.Name,
+		"slug":        category.Slug,
+		"description": category.Description,
+		"cover":       category.Cover,
+		"sort":        category.Sort,
+	}).Error; err != nil {
+		return nil, s.hm.GetMessage(1003, err)
+	}
+	s.mc.Delete(s.hm.GetCacheKey(13))
+	return
+}
+
+// DeleteCategory 删除分类，子分类上移一级，文章变为未分类
+func (s *service) DeleteCategory(id int64) (err error) {
+	category, err := s.GetCategory(id)
+	if err != nil {
+		return
+	}
+	tx := s.db.Begin()
+	if err = tx.Model(&model.Category{}).Where("parent_id = ?", id).
+		Update("parent_id", category.ParentID).Error; err != nil {
+		tx.Rollback()
+		return s.hm.GetMessage(1003, err)
+	}
+	if err = tx.Model(&model.Article{}).Where("category_id = ?", id).
+		UpdateColumn("category_id", 0).Error; err != nil {
+		tx.Rollback()
+		return s.hm.GetMessage(1003, err)
+	}
+	if err = tx.Delete(category).Error; err != nil {
+		tx.Rollback()
+		return s.hm.GetMessage(1004, err)
+	}
+	tx.Commit()
+	s.mc.Delete(s.hm.GetCacheKey(13))
+	return
+}
+
+// SortCategories 保存拖拽后的层级与顺序
+func (s *service) SortCategories(nodes []*model.CategorySortReq) (err error) {
+	var list []*model.Category
+	if err = s.db.Select("id, parent_id").Find(&list).Error; err != nil {
+		return s.hm.GetMessage(1001, err)
+	}
+	// 以完整的分类树为基础，叠加本次调整后再检查
+	parents := make(map[int64]int64, len(list))
+	for _, c := range list {
+		parents[c.ID] = c.ParentID
+	}
+	for _, n := range nodes {
+		if _, ok := parents[n.ID]; !ok {
+			return s.hm.GetMessage(1001, "分类不存在")
+		}
+		if _, ok := parents[n.ParentID]; n.ParentID > 0 && !ok {
+			return s.hm.GetMessage(1001, "上级分类不存在")
+		}
+	}
+	for _, n := range nodes {
+		parents[n.ID] = n.ParentID
+	}
+	if categoryCycle(parents) {
+		return s.hm.GetMessage(1003, "分类层级不能形成循环")
+	}
+	tx := s.db.Begin()
+	for _, n := range nodes {
+		if err = tx.Model(&model.Category{}).Where("id = ?", n.ID).Updates(map[string]interface{}{
+			"parent_id": n.ParentID,
+			"sort":      n.Sort,
+		}).Error; err != nil {
+			tx.Rollback()
+			return s.hm.GetMessage(1003, err)
+		}
+	}
+	tx.Commit()
+	s.mc.Delete(s.hm.GetCacheKey(13))
+	return
+}
+
+// categorySlug 别名留空时由名称生成，汉字转为拼音，与其他分类重复时追加序号；
+// 手动填写的别名重复时报错
+func (s *service) categorySlug(id int64, slug, name string) (string, error) {
+	if strings.TrimSpace(slug) != "" {
+		slug, ok := makeSlug(slug, "")
+		if !ok {
+			return "", s.hm.GetMessage(1002, "分类别名只能包含小写字母、数字与连字符")
+		}
+		taken, err := s.categorySlugTaken(slug, id)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", s.hm.GetMessage(1002, fmt.Sprintf("分类别名「%s」已存在", slug))
+		}
+		return slug, nil
+	}
+	base := Slugify(name)
+	if base == "" {
+		return "", s.hm.GetMessage(1002, "无法由名称生成分类别名，请手动填写")
+	}
+	slug = base
+	for i := 2; ; i++ {
+		taken, err := s.categorySlugTaken(slug, id)
+		if err != nil || !taken {
+			return slug, err
+		}
+		if i > 100 {
+			return "", s.hm.GetMessage(1002, "无法生成唯一的分类别名，请手动填写")
+		}
+		suffix := fmt.Sprintf("-%d", i)
+		slug = strings.TrimRight(cutSlug(base, maxSlugLen-len(suffix)), "-") + suffix
+	}
+}
+
+// categorySlugTaken 别名是否已被其他分类使用
+func (s *service) categorySlugTaken(slug string, id int64) (taken bool, err error) {
+	var count int64
+	if err = s.db.Model(&model.Category{}).Where("slug = ? and id <> ?", slug, id).
+		Count(&count).Error; err != nil {
+		return false, s.hm.GetMessage(1001, err)
+	}
+	return count > 0, nil
+}
+
+// categoryCycle 检查 分类ID -> 上级ID 的关系中是否存在环
+func categoryCycle(parents map[int64]int64) bool {
+	for id := range parents {
+		seen := map[int64]bool{id: true}
+		for p := parents[id]; p > 0; p = parents[p] {
+			if seen[p] {
+				return true
+			}
+			seen[p] = true
+		}
+	}
+	return false
+}
+
+// makeSlug 别名只允许小写字母、数字与连字符，留空时由名称中的英文与数字生成
+func makeSlug(slug, name string) (string, bool) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if slug == "" {
+		slug = strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+		}), "-")
+	}
+	return slug, slugPattern.MatchString(slug)
+}
+
+// 上级分类必须存在，且不能是自身或自身的子分类
+func (s *service) checkCategoryParent(id, parentID int64) (err error) {
+	if parentID == 0 {
+		return
+	}
+	tree, err := s.GetCategoryTree()
+	if err != nil {
+		return
+	}
+	if _, ok := tree.Index[parentID]; !ok {
+		return s.hm.GetMessage(1001, "上级分类不存在")
+	}
+	if id == 0 {
+		return
+	}
+	for _, d := range tree.Descendants(id) {
+		if d == parentID {
+			return s.hm.GetMessage(1003, "不能移动到自身或子分类下")
+		}
+	}
+	return
+}
+
+// 补全文章的分类
+func (s *service) setArticleCategory(articles ...*model.Article) (err error) {
+	tree, err := s.GetCategoryTree()
+	if err != nil {
+		return
+	}
+	for _, a := range articles {
+		if c, ok := tree.Index[a.CategoryID]; ok {
+			a.Category = c
+		}
+	}
+	return
+}
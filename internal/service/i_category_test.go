@@ -0,0 +1,16 @@
+package service
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestCategoryCycle(t *testing.T) {
+	Convey("categoryCycle", t, func() {
+		So(categoryCycle(map[int64]int64{1: 0, 2: 1, 3: 2}), ShouldBeFalse)
+		So(categoryCycle(map[int64]int64{1: 2, 2: 1}), ShouldBeTrue)
+		// 只调整了 3，但与未提交的 1、2 形成环
+		So(categoryCycle(map[int64]int64{1: 3, 2: 1, 3: 2}), ShouldBeTrue)
+		So(categoryCycle(map[int64]int64{1: 1}), ShouldBeTrue)
+	})
+}
@@ -1,7 +1,9 @@
 package service
 
 import (
+	"ginana-blog/internal/metrics"
 	"ginana-blog/internal/model"
+	"ginana-blog/internal/render"
 )
 
 func (s *service) GetComments(p *model.Pager, prs ...model.CommentQueryParam) (res *model.Comments, err error) {
@@ -65,13 +67,15 @@ func (s *service) CreateComment(req *model.CreateCommentReq) (err error) {
 	comment.ObjPK = req.ObjPK
 	comment.ReplyPK = req.ReplyPK
 	comment.ReplyFK = req.ReplyFK
-	comment.Content = req.Content
+	comment.Content = render.Text(render.KindComment, req.Content)
 	comment.ObjPKType = req.ObjPKType
 	comment.IPAddress = req.IPAddress
 	comment.UserID = req.UserID
 	if err = s.db.Create(comment).Error; err != nil {
+		metrics.Comments.Inc("failed")
 		return s.hm.GetMessage(1002, err)
 	}
+	metrics.Comments.Inc("created")
 	s.mc.Delete(s.hm.GetCacheKey(6))
 	return
 }
@@ -82,7 +86,7 @@ func (s *service) UpdateComment(req *model.UpdateCommentReq) (err error) {
 	if err = s.db.Find(comment).Error; err != nil {
 		return s.hm.GetMessage(1001, err)
 	}
-	if err = s.db.Model(comment).Update("content", req.Content).Error; err != nil {
+	if err = s.db.Model(comment).Update("content", render.Text(render.KindComment, req.Content)).Error; err != nil {
 		return s.hm.GetMessage(1002, err)
 	}
 	s.mc.Delete(s.hm.GetCacheKey(6))
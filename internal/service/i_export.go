@@ -0,0 +1,40 @@
+package service
+
+import (
+	"ginana-blog/internal/model"
+)
+
+// GetExportData 读取导出所需的全部内容，包括草稿与回收站中的文章
+func (s *service) GetExportData() (data *model.ExportData, err error) {
+	data = &model.ExportData{Comments: make(map[int64][]*model.Comment)}
+	if err = s.db.Preload("Tags").Preload("User").Order("id asc").Find(&data.Articles).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	var comments []*model.Comment
+	if err = s.db.Preload("User").Order("id asc").Find(&comments).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	for _, c := range comments {
+		key := c.ObjPK
+		if c.ObjPKType != 0 {
+			key = 0
+		}
+		data.Comments[key] = append(data.Comments[key], c)
+	}
+	if err = s.db.Order("id asc").Find(&data.Moods).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	if err = s.db.Preload("Aliases").Order("id asc").Find(&data.Tags).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	if err = s.db.Order("sort asc, id asc").Find(&data.Categories).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	if err = s.db.Order("id asc").Find(&data.Series).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	if err = s.db.Order("series_id asc, sort asc, id asc").Find(&data.SeriesArticles).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	return
+}
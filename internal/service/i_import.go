@@ -0,0 +1,365 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/render"
+	"github.com/jinzhu/gorm"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 导入文章未指定作者时归属的用户
+const importDefaultUser = 1
+
+// ImportContent 将解析后的内容写入数据库，全部在一个事务内完成，试运行时回滚
+func (s *service) ImportContent(data *model.ImportData, dryRun bool) (report *model.ImportReport, err error) {
+	report = &model.ImportReport{Format: data.Format, DryRun: dryRun, Images: data.Images}
+	report.Warnings = append(report.Warnings, data.Warnings...)
+	tx := s.db.Begin()
+	if err = tx.Error; err != nil {
+		return nil, s.hm.GetMessage(500, err)
+	}
+	imp := &contentImport{
+		s:      s.withDB(tx),
+		report: report,
+		users:  make(map[string]int64),
+	}
+	if err = imp.run(data); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if dryRun {
+		tx.Rollback()
+		return
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, s.hm.GetMessage(1002, err)
+	}
+	s.mc.Delete(s.hm.GetCacheKey(6))
+	s.mc.Delete(s.hm.GetCacheKey(15))
+	s.deleteArticleCache()
+	return
+}
+
+// withDB 返回使用指定连接（通常为事务）的服务副本
+func (s *service) withDB(db *gorm.DB) *service {
+	c := *s
+	c.db = db
+	return &c
+}
+
+type contentImport struct {
+	s      *service
+	report *model.ImportReport
+	users  map[string]int64 // 原站用户标识及游客 -> 用户ID
+}
+
+func (imp *contentImport) run(data *model.ImportData) (err error) {
+	var tagsBefore, tagsAfter int64
+	imp.s.db.Model(&model.Tag{}).Count(&tagsBefore)
+	for _, u := range data.Users {
+		if imp.users[u.Key], err = imp.user(u, false); err != nil {
+			return
+		}
+	}
+	for _, t := range data.Tags {
+		if err = imp.tag(t); err != nil {
+			return
+		}
+	}
+	posts := append([]*model.ImportPost(nil), data.Posts...)
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].CreatedAt.Before(posts[j].CreatedAt)
+	})
+	for _, p := range posts {
+		if err = imp.post(p); err != nil {
+			return
+		}
+	}
+	imp.s.db.Model(&model.Tag{}).Count(&tagsAfter)
+	imp.report.Tags = int(tagsAfter - tagsBefore)
+	return
+}
+
+// user 原站作者按用户名或邮箱匹配已有用户，否则创建未激活的新用户。
+// 游客留下的邮箱未经验证，总是创建新用户，避免评论被归到同邮箱的站内账号
+func (imp *contentImport) user(u *model.ImportUser, guest bool) (id int64, err error) {
+	s := imp.s
+	user := new(model.User)
+	username := strings.TrimSpace(u.Username)
+	email := strings.TrimSpace(u.Email)
+	if !guest && username != "" && s.db.Find(user, "username = ?", username).Error == nil {
+		return user.ID, nil
+	}
+	if !guest && email != "" && s.db.Find(user, "email = ?", email).Error == nil {
+		return user.ID, nil
+	}
+	if username == "" {
+		username = Slugify(strings.SplitN(email, "@", 2)[0])
+	}
+	if username == "" {
+		username = Slugify(u.Nickname)
+	}
+	if username == "" {
+		username = "user"
+	}
+	nickname := strings.TrimSpace(u.Nickname)
+	if nickname == "" {
+		nickname = username
+	}
+	user = &model.User{Email: email, IsAuth: false}
+	if user.Username, err = imp.unique("username", truncate(username, 150)); err != nil {
+		return
+	}
+	if user.Nickname, err = imp.unique("nickname", truncate(nickname, 90)); err != nil {
+		return
+	}
+	user.Password = s.tool.BcryptHashGenerate(randomPassword())
+	if err = s.db.Create(user).Error; err != nil {
+		return 0, s.hm.GetMessage(1002, err)
+	}
+	imp.report.Users++
+	return user.ID, nil
+}
+
+// unique 用户名或昵称重复时追加序号
+func (imp *contentImport) unique(column, base string) (value string, err error) {
+	value = base
+	for i := 2; ; i++ {
+		var count int64
+		if err = imp.s.db.Model(&model.User{}).Where(column+" = ?", value).Count(&count).Error; err != nil {
+			return "", imp.s.hm.GetMessage(1001, err)
+		}
+		if count == 0 {
+			return
+		}
+		value = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// tag 创建带URL别名与描述的标签，已存在的标签保持不变
+func (imp *contentImport) tag(t *model.ImportTag) (err error) {
+	s := imp.s
+	name := model.NormalizeTagName(t.Name)
+	if name == "" {
+		return
+	}
+	if utf8.RuneCountInString(name) > 50 {
+		imp.report.Warnf("标签「%s」超过50个字符，已忽略", name)
+		return
+	}
+	if _, err = s.GetTagByName(name); err == nil {
+		return
+	} else if err != gorm.ErrRecordNotFound {
+		return
+	}
+	tag := s.newTag(name)
+	if slug, ok := makeSlug(t.Slug, name); ok {
+		var count int64
+		s.db.Model(&model.Tag{}).Where("slug = ?", slug).Count(&count)
+		if count == 0 {
+			tag.Slug = slug
+		}
+	}
+	tag.Description = truncate(t.Description, 255)
+	if err = s.db.Create(tag).Error; err != nil {
+		return s.hm.GetMessage(1002, err)
+	}
+	return
+}
+
+// post 导入单篇文章，同名文章已存在时跳过
+func (imp *contentImport) post(p *model.ImportPost) (err error) {
+	s := imp.s
+	item := &model.ImportItem{Title: strings.TrimSpace(p.Title), Source: p.Source}
+	imp.report.Items = append(imp.report.Items, item)
+	skip := func(note string) error {
+		item.Skipped = true
+		item.Note = note
+		imp.report.Skipped++
+		return nil
+	}
+	if item.Title == "" {
+		return skip("缺少标题")
+	}
+	if utf8.RuneCountInString(item.Title) > 100 {
+		item.Title = truncate(item.Title, 100)
+		item.Note = "标题超过100个字符，已截断"
+	}
+	var count int64
+	s.db.Model(&model.Article{}).Where("title = ?", item.Title).Count(&count)
+	if count > 0 {
+		return skip("同名文章已存在")
+	}
+	article := new(model.Article)
+	article.Title = item.Title
+	if p.Slug != "" {
+		if article.Urlname, err = s.ArticleSlug(article.Title, p.Slug, 1, 0); err != nil {
+			imp.report.Warnf("「%s」的URL名称「%s」不可用（%v），已重新生成", article.Title, p.Slug, err)
+			article.Urlname, err = s.ArticleSlug(article.Title, "", 1, 0)
+		}
+		if err == nil && article.Urlname != "" {
+			article.Urltype = 1
+		}
+	}
+	article.Content = render.Markdown(render.KindArticle, p.Content)
+	article.Cover = p.Cover
+	article.UserID = importDefaultUser
+	if id, ok := imp.users[p.Author]; ok {
+		article.UserID = id
+	}
+	if p.Draft {
+		article.Status = 1
+	}
+	article.CreatedAt = p.CreatedAt
+	if article.CreatedAt.IsZero() {
+		article.CreatedAt = time.Now()
+	}
+	article.UpdatedAt = p.UpdatedAt
+	if article.UpdatedAt.Before(article.CreatedAt) {
+		article.UpdatedAt = article.CreatedAt
+	}
+	if err = s.setArticleStats(article); err != nil {
+		return skip(err.Error())
+	}
+	var names []string
+	for _, name := range p.Tags {
+		if name = model.NormalizeTagName(name); utf8.RuneCountInString(name) > 50 {
+			imp.report.Warnf("标签「%s」超过50个字符，已忽略", name)
+			continue
+		}
+		names = append(names, strings.Replace(name, ",", " ", -1))
+	}
+	if article.Tags, err = s.articleTags(strings.Join(names, ",")); err != nil {
+		return
+	}
+	if err = s.db.Create(article).Error; err != nil {
+		return s.hm.GetMessage(1002, err)
+	}
+	if err = s.clearRedirectSource(s.db, article.Link()); err != nil {
+		return
+	}
+	item.Link = article.Link()
+	imp.report.Articles++
+	for _, alias := range p.Aliases {
+		if err = imp.redirect(alias, article.Link()); err != nil {
+			return
+		}
+	}
+	item.Comments, err = imp.comments(article.ID, p.Comments)
+	return
+}
+
+// redirect 原站链接跳转到导入后的文章
+func (imp *contentImport) redirect(alias, link string) (err error) {
+	s := imp.s
+	u, e := url.Parse(strings.TrimSpace(alias))
+	if e != nil || u.Path == "" || u.Path == "/" {
+		return
+	}
+	source := u.Path
+	if target, _ := url.PathUnescape(link); source == target || utf8.RuneCountInString(source) > 191 {
+		return
+	}
+	var count int64
+	s.db.Model(&model.Redirect{}).Where("source = ?", source).Count(&count)
+	if count > 0 {
+		imp.report.Warnf("跳转「%s」已存在，未覆盖", source)
+		return
+	}
+	redirect := &model.Redirect{Source: source, Target: link, Code: 301, Auto: true}
+	if err = s.db.Create(redirect).Error; err != nil {
+		return s.hm.GetMessage(1002, err)
+	}
+	imp.report.Redirects++
+	return
+}
+
+// comments 按时间顺序导入评论，回复关联到被回复评论及其所在的顶层评论
+func (imp *contentImport) comments(articleID int64, list []*model.ImportComment) (n int, err error) {
+	s := imp.s
+	type node struct{ id, root int64 }
+	created := make(map[string]node)
+	pending := append([]*model.ImportComment(nil), list...)
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	for len(pending) > 0 {
+		var next []*model.ImportComment
+		for _, c := range pending {
+			comment := &model.Comment{
+				ObjPK:     articleID,
+				Content:   render.Text(render.KindComment, c.Content),
+				IPAddress: c.IP,
+				CreatedAt: c.CreatedAt,
+			}
+			if c.ParentKey != "" {
+				parent, ok := created[c.ParentKey]
+				if !ok {
+					next = append(next, c)
+					continue
+				}
+				comment.ReplyPK = parent.id
+				comment.ReplyFK = parent.root
+			}
+			if comment.UserID, err = imp.commentUser(c); err != nil {
+				return
+			}
+			if err = s.db.Create(comment).Error; err != nil {
+				return n, s.hm.GetMessage(1002, err)
+			}
+			root := comment.ReplyFK
+			if root == 0 {
+				root = comment.ID
+			}
+			created[c.Key] = node{comment.ID, root}
+			n++
+		}
+		// 剩余评论的上级不存在，作为顶层评论导入
+		if len(next) == len(pending) {
+			for _, c := range next {
+				imp.report.Warnf("评论「%s」的上级评论不存在，已作为顶层评论导入", c.Key)
+				c.ParentKey = ""
+			}
+		}
+		pending = next
+	}
+	imp.report.Comments += n
+	return
+}
+
+func (imp *contentImport) commentUser(c *model.ImportComment) (id int64, err error) {
+	if id, ok := imp.users[c.User]; ok && c.User != "" {
+		return id, nil
+	}
+	// 同一游客的多条评论归到同一个新用户，邮箱与昵称都相同才视为同一游客
+	key := "guest:" + strings.ToLower(strings.TrimSpace(c.Email)) + "|" + strings.TrimSpace(c.Author)
+	if id, ok := imp.users[key]; ok {
+		return id, nil
+	}
+	if id, err = imp.user(&model.ImportUser{Nickname: c.Author, Email: c.Email}, true); err != nil {
+		return
+	}
+	imp.users[key] = id
+	return
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// 导入用户的随机初始密码，需由管理员重置后使用
+func randomPassword() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"ginana-blog/internal/model"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestImportContent(t *testing.T) {
+	requireDB(t)
+	Convey("ImportContent", t, func() {
+		created := time.Date(2019, 1, 2, 8, 30, 0, 0, time.Local)
+		data := &model.ImportData{
+			Format: model.ImportWXR,
+			Users:  []*model.ImportUser{{Key: "writer", Username: "import-writer", Nickname: "导入作者"}},
+			Posts: []*model.ImportPost{{
+				Title:     "导入测试文章",
+				Slug:      "import-test",
+				Content:   "正文",
+				Author:    "writer",
+				CreatedAt: created,
+				Tags:      []string{"导入测试"},
+				Aliases:   []string{"/2019/01/02/import-test/"},
+				Comments: []*model.ImportComment{
+					{Key: "2", ParentKey: "1", Author: "路人", Content: "回复", CreatedAt: created.Add(2 * time.Hour)},
+					{Key: "1", Author: "路人", Content: "评论", CreatedAt: created.Add(time.Hour)},
+				},
+			}},
+		}
+		Convey("试运行不写入数据", func() {
+			report, err := svc.ImportContent(data, true)
+			So(err, ShouldBeNil)
+			So(report.Articles, ShouldEqual, 1)
+			So(report.Users, ShouldEqual, 2)
+			So(report.Comments, ShouldEqual, 2)
+			So(report.Redirects, ShouldEqual, 1)
+			So(report.Items[0].Link, ShouldEqual, "/s/import-test")
+			_, err = svc.GetArticleByUrlName("import-test")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"ginana-blog/internal/metrics"
 	"ginana-blog/internal/model"
 	"github.com/jinzhu/gorm"
 )
@@ -18,6 +19,13 @@ func (s *service) GetCaptcha() (res *model.Captcha, err error) {
 }
 
 func (s *service) PostLogin(req *model.UserLoginReq) (user *model.User, err error) {
+	defer func() {
+		if err != nil {
+			metrics.Logins.Inc("failure")
+			return
+		}
+		metrics.Logins.Inc("success")
+	}()
 	user, err = s.GetUserByUsername(req.Username)
 	if err != nil {
 		return nil, s.hm.GetMessage(1001, err)
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"ginana-blog/internal/model"
+	mc "github.com/bradfitz/gomemcache/memcache"
+)
+
+// FlushCache 清空全部缓存
+func (s *service) FlushCache() (err error) {
+	if err = s.mc.FlushAll(); err != nil {
+		return s.hm.GetMessage(500, err)
+	}
+	return
+}
+
+// Migrate 按模型补建数据表与字段，不删除已有字段
+func (s *service) Migrate() (tables []string, err error) {
+	if err = s.db.AutoMigrate(model.Tables...).Error; err != nil {
+		return nil, s.hm.GetMessage(500, err)
+	}
+	for _, m := range model.Tables {
+		tables = append(tables, s.db.NewScope(m).TableName())
+	}
+	return
+}
+
+// Reindex 重新计算全部文章的字数、阅读时长与标题大纲，并清空文章相关缓存
+func (s *service) Reindex() (count int, err error) {
+	var ids []int64
+	if err = s.db.Model(&model.Article{}).Order("id asc").Pluck("id", &ids).Error; err != nil {
+		return 0, s.hm.GetMessage(1001, err)
+	}
+	for _, id := range ids {
+		article := new(model.Article)
+		if err = s.db.Select("id, content").Find(article, "id = ?", id).Error; err != nil {
+			return count, s.hm.GetMessage(1001, err)
+		}
+		if err = s.setArticleStats(article); err != nil {
+			return
+		}
+		if err = s.db.Model(article).UpdateColumns(map[string]interface{}{
+			"words":     article.Words,
+			"read_time": article.ReadTime,
+			"outline":   article.Outline,
+		}).Error; err != nil {
+			return count, s.hm.GetMessage(1003, err)
+		}
+		count++
+	}
+	s.deleteArticleCache()
+	return
+}
+
+// CheckReady 检查数据库、缓存与权限规则是否可用，键为检查项，值为 nil 表示正常
+func (s *service) CheckReady() (checks map[string]error) {
+	checks = map[string]error{"db": s.db.DB().Ping(), "cache": nil}
+	// 访问一个从不写入的键，未命中说明缓存服务可达
+	if err := s.mc.Touch("readyz_probe", 1); err != nil && err != mc.ErrCacheMiss {
+		checks["cache"] = err
+	}
+	if s.cfg.Casbin != nil && s.cfg.Casbin.Enable {
+		checks["casbin"] = nil
+		if s.ef == nil {
+			checks["casbin"] = errors.New("权限规则未载入")
+		}
+	}
+	return
+}
+
+// FixDuplicateSlugs 为旧数据中重复的URL名称追加序号。前台按 urltype desc, id asc 取第一篇，
+// 该文章保持不变，其余文章原本就无法通过该名称访问，改名后各自可以访问
+func (s *service) FixDuplicateSlugs() (renamed []*model.Article, err error) {
+	var slugs []string
+	if err = s.db.Model(&model.Article{}).Where("urlname <> ''").Group("urlname").
+		Having("count(*) > 1").Pluck("urlname", &slugs).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	for _, slug := range slugs {
+		var articles []*model.Article
+		if err = s.db.Select("id, urlname, urltype").Order("urltype desc, id asc").
+			Find(&articles, "urlname = ?", slug).Error; err != nil {
+			return nil, s.hm.GetMessage(1001, err)
+		}
+		for _, article := range articles[1:] {
+			if article.Urlname, err = s.uniqueSlug(slug, article.ID); err != nil {
+				return
+			}
+			if err = s.db.Model(article).UpdateColumn("urlname", article.Urlname).Error; err != nil {
+				return nil, s.hm.GetMessage(1003, err)
+			}
+			renamed = append(renamed, article)
+		}
+	}
+	if len(renamed) > 0 {
+		s.deleteArticleCache()
+	}
+	return
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"ginana-blog/internal/model"
+	"ginana-blog/internal/render"
 )
 
 func (s *service) GetMoods(p *model.Pager) (res *model.Moods, err error) {
@@ -11,13 +12,21 @@ func (s *service) GetMoods(p *model.Pager) (res *model.Moods, err error) {
 	if err = query.Limit(p.PageSize).Offset((p.Page - 1) * p.PageSize).Find(&res.List).Error; err != nil {
 		return nil, s.hm.GetMessage(1001, err)
 	}
+	// 与文章、评论一样在服务端渲染，不再交给浏览器端的编辑器解析
+	for _, mood := range res.List {
+		r, err := render.Render(render.KindMood, mood.Content)
+		if err != nil {
+			return nil, s.hm.GetMessage(500, err)
+		}
+		mood.HTML = r.HTML
+	}
 	res.Pager = p
 	return
 }
 
 func (s *service) CreateMood(req *model.MoodReq) (err error) {
 	mood := new(model.Mood)
-	mood.Content = req.ContentMarkdownDoc
+	mood.Content = render.Markdown(render.KindMood, req.ContentMarkdownDoc)
 	mood.Cover = req.Cover
 	if err = s.db.Create(mood).Error; err != nil {
 		return s.hm.GetMessage(1002)
@@ -92,3 +92,28 @@ func (s *service) DeletePolicy(id int64) (err error) {
 	s.ef.LoadPolicy()
 	return
 }
+
+// SyncPolices 将规则文件中缺少的规则写入数据库，按请求路由与请求方式判断是否已存在
+func (s *service) SyncPolices(polices []*model.Policy) (created []*model.Policy, err error) {
+	all, err := s.GetAllPolices()
+	if err != nil {
+		return
+	}
+	exists := make(map[string]bool)
+	for _, p := range all.List {
+		exists[p.Router+" "+strings.ToUpper(p.Method)] = true
+	}
+	for _, p := range polices {
+		key := p.Router + " " + strings.ToUpper(p.Method)
+		if exists[key] {
+			continue
+		}
+		exists[key] = true
+		policy := &model.Policy{Name: p.Name, Router: p.Router, Method: strings.ToUpper(p.Method)}
+		if err = s.db.Create(policy).Error; err != nil {
+			return created, s.hm.GetMessage(1002, err)
+		}
+		created = append(created, policy)
+	}
+	return
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"ginana-blog/internal/model"
+	"github.com/jinzhu/gorm"
+)
+
+// 游客的Cookie标识与IP分别哈希后保存，清除Cookie后同一IP仍视为已回应，登录用户不使用
+func reactionVisitor(userID int64, visitorID, ip string) (visitor, ipHash string) {
+	if userID > 0 {
+		return "", ""
+	}
+	return hashString(visitorID), hashString(ip)
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// reactionQuery 当前访客的回应，游客的Cookie或IP任一相同即视为同一访客
+func reactionQuery(db *gorm.DB, articleID, userID int64, visitorID, ip string) *gorm.DB {
+	visitor, ipHash := reactionVisitor(userID, visitorID, ip)
+	db = db.Model(&model.Reaction{}).Where("article_id = ? and user_id = ?", articleID, userID)
+	if userID > 0 {
+		return db
+	}
+	return db.Where("visitor = ? or ip_hash = ?", visitor, ipHash)
+}
+
+// GetArticleReactions 文章各表情的回应数，并标记当前访客已回应的表情
+func (s *service) GetArticleReactions(articleID, userID int64, visitorID, ip string) (list []*model.ReactionCount, err error) {
+	options, err := s.GetSiteOptions()
+	if err != nil {
+		return
+	}
+	var counts []*model.ReactionCount
+	if err = s.db.Model(&model.Reaction{}).Select("name, count(*) as count").
+		Where("article_id = ?", articleID).Group("name").Scan(&counts).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	countMap := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		countMap[c.Name] = c.Count
+	}
+	var reacted []string
+	if userID > 0 || visitorID != "" {
+		if err = reactionQuery(s.db, articleID, userID, visitorID, ip).
+			Pluck("name", &reacted).Error; err != nil {
+			return nil, s.hm.GetMessage(1001, err)
+		}
+	}
+	reactedMap := make(map[string]bool, len(reacted))
+	for _, name := range reacted {
+		reactedMap[name] = true
+	}
+	for _, t := range options.GetReactions() {
+		list = append(list, &model.ReactionCount{
+			Name:    t.Name,
+			Emoji:   t.Emoji,
+			Count:   countMap[t.Name],
+			Reacted: reactedMap[t.Name],
+		})
+	}
+	return
+}
+
+// ToggleReaction 回应或取消回应，返回最新的回应数
+func (s *service) ToggleReaction(req *model.ReactionReq) (list []*model.ReactionCount, err error) {
+	options, err := s.GetSiteOptions()
+	if err != nil {
+		return
+	}
+	valid := false
+	for _, t := range options.GetReactions() {
+		if t.Name == req.Name {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, s.hm.GetMessage(1002, "不支持的表情")
+	}
+	if req.UserID <= 0 && req.VisitorID == "" {
+		return nil, s.hm.GetMessage(1002, "无法识别访客")
+	}
+	article := new(model.Article)
+	if err = s.db.Select("id").Find(article, "id = ? and status = 0", req.ArticleID).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	reaction := &model.Reaction{
+		ArticleID: req.ArticleID,
+		Name:      req.Name,
+		UserID:    req.UserID,
+	}
+	reaction.Visitor, reaction.IPHash = reactionVisitor(req.UserID, req.VisitorID, req.IP)
+	tx := s.db.Begin()
+	// 只能取消同一Cookie或登录用户自己的回应
+	res := tx.Where("article_id = ? and name = ? and user_id = ? and visitor = ?",
+		reaction.ArticleID, reaction.Name, reaction.UserID, reaction.Visitor).Delete(&model.Reaction{})
+	if res.Error != nil {
+		tx.Rollback()
+		return nil, s.hm.GetMessage(1004, res.Error)
+	}
+	delta := -res.RowsAffected
+	if res.RowsAffected == 0 {
+		var count int64
+		if err = reactionQuery(tx, req.ArticleID, req.UserID, req.VisitorID, req.IP).
+			Where("name = ?", req.Name).Count(&count).Error; err != nil {
+			tx.Rollback()
+			return nil, s.hm.GetMessage(1001, err)
+		}
+		if count > 0 {
+			tx.Rollback()
+			return nil, s.hm.GetMessage(1002, "已经回应过了")
+		}
+		if err = tx.Create(reaction).Error; err != nil {
+			tx.Rollback()
+			return nil, s.hm.GetMessage(1002, err)
+		}
+		delta = 1
+	}
+	if err = tx.Model(article).UpdateColumn("likes", gorm.Expr("likes + ?", delta)).Error; err != nil {
+		tx.Rollback()
+		return nil, s.hm.GetMessage(1003, err)
+	}
+	tx.Commit()
+	s.mc.Delete(s.hm.GetCacheKey(12))
+	return s.GetArticleReactions(req.ArticleID, req.UserID, req.VisitorID, req.IP)
+}
+
+func (s *service) GetLikedArticles(limit int) (articles []*model.Article, err error) {
+	key := s.hm.GetCacheKey(12)
+	err = s.mc.Get(key, &articles)
+	if err != nil {
+		if err = s.db.Model(&articles).Where("status = 0 and likes > 0 and visibility = ?", model.VisibilityPublic).
+			Order("likes desc").Limit(limit).Find(&articles).Error; err != nil {
+			return nil, s.hm.GetMessage(1001, err)
+		}
+		if err = s.mc.Set(key, &articles); err != nil {
+			return nil, s.hm.GetMessage(1002, err)
+		}
+	}
+	return
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"ginana-blog/internal/model"
+	"github.com/griffin702/ginana/library/log"
+	"github.com/jinzhu/gorm"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redirectHits 跳转命中计数，先记在内存中，随浏览量一起定时写入
+type redirectHits struct {
+	mu      sync.Mutex
+	pending map[int64]int64
+	last    map[int64]time.Time
+}
+
+func newRedirectHits() *redirectHits {
+	return &redirectHits{
+		pending: make(map[int64]int64),
+		last:    make(map[int64]time.Time),
+	}
+}
+
+func (h *redirectHits) add(id int64, now time.Time) {
+	h.mu.Lock()
+	h.pending[id]++
+	h.last[id] = now
+	h.mu.Unlock()
+}
+
+func (h *redirectHits) get(id int64) int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.pending[id]
+}
+
+func (h *redirectHits) take() (pending map[int64]int64, last map[int64]time.Time) {
+	h.mu.Lock()
+	pending, last = h.pending, h.last
+	h.pending = make(map[int64]int64)
+	h.last = make(map[int64]time.Time)
+	h.mu.Unlock()
+	return
+}
+
+func (h *redirectHits) restore(id, n int64, t time.Time) {
+	h.mu.Lock()
+	h.pending[id] += n
+	if t.After(h.last[id]) {
+		h.last[id] = t
+	}
+	h.mu.Unlock()
+}
+
+func (s *service) GetRedirects(p *model.Pager) (res *model.Redirects, err error) {
+	res = new(model.Redirects)
+	query := s.db.Model(&res.List)
+	query.Count(&p.AllCount)
+	if err = query.Order("id desc").Limit(p.PageSize).Offset((p.Page - 1) * p.PageSize).
+		Find(&res.List).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	for _, r := range res.List {
+		r.Hits += s.redirects.get(r.ID)
+	}
+	res.Pager = p
+	return
+}
+
+func (s *service) GetRedirect(id int64) (redirect *model.Redirect, err error) {
+	redirect = new(model.Redirect)
+	if err = s.db.Find(redirect, "id = ?", id).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	return
+}
+
+func (s *service) CreateRedirect(req *model.CreateRedirectReq) (redirect *model.Redirect, err error) {
+	redirect = new(model.Redirect)
+	redirect.Source = strings.TrimSpace(req.Source)
+	redirect.Target = strings.TrimSpace(req.Target)
+	redirect.Code = req.Code
+	if err = s.checkRedirect(redirect); err != nil {
+		return nil, err
+	}
+	if err = s.db.Create(redirect).Error; err != nil {
+		return nil, s.hm.GetMessage(1002, err)
+	}
+	s.mc.Delete(s.hm.GetCacheKey(15))
+	return
+}
+
+func (s *service) UpdateRedirect(req *model.UpdateRedirectReq) (redirect *model.Redirect, err error) {
+	redirect, err = s.GetRedirect(req.ID)
+	if err != nil {
+		return
+	}
+	redirect.Source = strings.TrimSpace(req.Source)
+	redirect.Target = strings.TrimSpace(req.Target)
+	redirect.Code = req.Code
+	if err = s.checkRedirect(redirect); err != nil {
+		return nil, err
+	}
+	if err = s.db.Model(redirect).Updates(map[string]interface{}{
+		"source": redirect.Source,
+		"target": redirect.Target,
+		"code":   redirect.Code,
+	}).Error; err != nil {
+		return nil, s.hm.GetMessage(1003, err)
+	}
+	s.mc.Delete(s.hm.GetCacheKey(15))
+	return
+}
+
+func (s *service) DeleteRedirect(id int64) (err error) {
+	if err = s.db.Delete(&model.Redirect{}, "id = ?", id).Error; err != nil {
+		return s.hm.GetMessage(1004, err)
+	}
+	s.mc.Delete(s.hm.GetCacheKey(15))
+	return
+}
+
+// MatchRedirect 查找请求路径对应的跳转，精确规则优先，通配规则按前缀长度优先
+func (s *service) MatchRedirect(path string) (target string, code int, ok bool) {
+	rules, err := s.getRedirectRules()
+	if err != nil {
+		log.Errorf("load redirects error(%v)", err)
+		return
+	}
+	for _, r := range rules {
+		if target, ok = r.Match(path); ok {
+			s.redirects.add(r.ID, time.Now())
+			return target, r.Code, true
+		}
+	}
+	return
+}
+
+// getRedirectRules 全部跳转规则，已按匹配优先级排序
+func (s *service) getRedirectRules() (rules []*model.Redirect, err error) {
+	key := s.hm.GetCacheKey(15)
+	if err = s.mc.Get(key, &rules); err != nil {
+		if err = s.db.Select("id, source, target, code").Find(&rules).Error; err != nil {
+			return nil, s.hm.GetMessage(1001, err)
+		}
+		sort.SliceStable(rules, func(i, j int) bool {
+			a, b := rules[i], rules[j]
+			if a.IsWildcard() != b.IsWildcard() {
+				return !a.IsWildcard()
+			}
+			return strings.Index(a.Source+"*", "*") > strings.Index(b.Source+"*", "*")
+		})
+		if err = s.mc.Set(key, &rules); err != nil {
+			return nil, s.hm.GetMessage(1002, err)
+		}
+	}
+	return
+}
+
+// 原路径必须以/开头，通配符最多一个，目标为站内路径或http(s)地址
+func (s *service) checkRedirect(r *model.Redirect) (err error) {
+	if r.Code != 301 && r.Code != 302 {
+		r.Code = 301
+	}
+	switch {
+	case !strings.HasPrefix(r.Source, "/"):
+		return s.hm.GetMessage(500, "原路径必须以 / 开头")
+	case strings.Count(r.Source, "*") > 1 || strings.Count(r.Target, "*") > 1:
+		return s.hm.GetMessage(500, "原路径与目标地址最多只能包含一个通配符 *")
+	case strings.Contains(r.Target, "*") && !r.IsWildcard():
+		return s.hm.GetMessage(500, "原路径不含通配符时，目标地址不能包含 *")
+	case !strings.HasPrefix(r.Target, "/") && !strings.HasPrefix(r.Target, "http://") &&
+		!strings.HasPrefix(r.Target, "https://"):
+		return s.hm.GetMessage(500, "目标地址必须是以 / 开头的站内路径或 http(s) 地址")
+	case r.Source == r.Target || r.Loops():
+		return s.hm.GetMessage(500, "目标地址会再次匹配原路径，造成循环跳转")
+	case strings.HasPrefix(r.Source, "/admin") || strings.HasPrefix(r.Source, "/static/"):
+		return s.hm.GetMessage(500, "不能为后台或静态资源路径设置跳转")
+	}
+	return
+}
+
+// saveArticleRedirect 文章链接变更后旧链接跳转到新链接，
+// 指向旧链接的跳转同时改为指向新链接，避免多次跳转
+func (s *service) saveArticleRedirect(tx *gorm.DB, oldLink, newLink string) (err error) {
+	if oldLink == newLink {
+		return
+	}
+	source, err := url.PathUnescape(oldLink)
+	if err != nil {
+		source = oldLink
+	}
+	if err = s.clearRedirectSource(tx, newLink); err != nil {
+		return
+	}
+	if err = tx.Model(&model.Redirect{}).Where("target = ?", oldLink).
+		Update("target", newLink).Error; err != nil {
+		return s.hm.GetMessage(1003, err)
+	}
+	if err = tx.Where(model.Redirect{Source: source}).
+		Assign(map[string]interface{}{"target": newLink, "code": 301, "auto": true}).
+		FirstOrCreate(&model.Redirect{}).Error; err != nil {
+		return s.hm.GetMessage(1002, err)
+	}
+	return
+}
+
+// clearRedirectSource 链接重新被文章使用时，删除以其为原路径的跳转
+func (s *service) clearRedirectSource(tx *gorm.DB, link string) (err error) {
+	source, e := url.PathUnescape(link)
+	if e != nil {
+		source = link
+	}
+	if err = tx.Delete(&model.Redirect{}, "source = ?", source).Error; err != nil {
+		return s.hm.GetMessage(1004, err)
+	}
+	return
+}
+
+func (s *service) flushRedirectHits() {
+	pending, last := s.redirects.take()
+	if len(pending) == 0 {
+		return
+	}
+	for id, n := range pending {
+		err := s.db.Model(&model.Redirect{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"hits":        gorm.Expr("hits + ?", n),
+			"last_hit_at": last[id],
+		}).Error
+		if err != nil {
+			log.Errorf("flush redirect(%d) hits error(%v)", id, err)
+			s.redirects.restore(id, n, last[id])
+		}
+	}
+}
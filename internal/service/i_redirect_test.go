@@ -0,0 +1,54 @@
+package service
+
+import (
+	"ginana-blog/internal/model"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestRedirect(t *testing.T) {
+	Convey("Match", t, func() {
+		r := &model.Redirect{Source: "/s/old", Target: "/s/new"}
+		target, ok := r.Match("/s/old")
+		So(ok, ShouldBeTrue)
+		So(target, ShouldEqual, "/s/new")
+		_, ok = r.Match("/s/old/x")
+		So(ok, ShouldBeFalse)
+		r = &model.Redirect{Source: "/old/*.html", Target: "/new/*"}
+		target, ok = r.Match("/old/a/b.html")
+		So(ok, ShouldBeTrue)
+		So(target, ShouldEqual, "/new/a/b")
+		_, ok = r.Match("/old/a.htm")
+		So(ok, ShouldBeFalse)
+		r = &model.Redirect{Source: "/old/*", Target: "/*"}
+		_, ok = r.Match("/old//evil.com")
+		So(ok, ShouldBeFalse)
+		_, ok = r.Match(`/old/\evil.com`)
+		So(ok, ShouldBeFalse)
+		target, ok = r.Match("/old/a")
+		So(ok, ShouldBeTrue)
+		So(target, ShouldEqual, "/a")
+	})
+	Convey("Loops", t, func() {
+		So((&model.Redirect{Source: "/a/*", Target: "/a/b/*"}).Loops(), ShouldBeTrue)
+		So((&model.Redirect{Source: "/a/*", Target: "/a/b"}).Loops(), ShouldBeTrue)
+		So((&model.Redirect{Source: "/a", Target: "/a?x=1"}).Loops(), ShouldBeTrue)
+		So((&model.Redirect{Source: "/a/*", Target: "/b/*"}).Loops(), ShouldBeFalse)
+		So((&model.Redirect{Source: "/old/*.html", Target: "/old/*"}).Loops(), ShouldBeFalse)
+		So((&model.Redirect{Source: "/a/*", Target: "https://example.com/a/*"}).Loops(), ShouldBeFalse)
+	})
+	Convey("redirectHits", t, func() {
+		h := newRedirectHits()
+		now := time.Now()
+		h.add(1, now)
+		h.add(1, now.Add(time.Second))
+		So(h.get(1), ShouldEqual, 2)
+		pending, last := h.take()
+		So(pending, ShouldResemble, map[int64]int64{1: 2})
+		So(last[1], ShouldEqual, now.Add(time.Second))
+		So(h.get(1), ShouldEqual, 0)
+		h.restore(1, 2, now)
+		So(h.get(1), ShouldEqual, 2)
+	})
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/recommend"
+	"strings"
+	"time"
+)
+
+// 参与相似度计算的正文长度（字符），避免每次加载全部正文
+const relatedExcerptLen = 2000
+
+// GetRelatedArticles 按标签重合度与正文相似度推荐相关文章
+// 结果按文章缓存，任意文章变更后整体失效
+func (s *service) GetRelatedArticles(id int64, limit int) (articles []*model.Article, err error) {
+	if limit <= 0 {
+		return
+	}
+	key := s.hm.GetCacheKey(10, fmt.Sprintf("%d_%d_%d", id, limit, s.relatedVersion()))
+	if err = s.mc.Get(key, &articles); err == nil {
+		return
+	}
+	// 只推荐公开文章，当前文章无论可见性都参与计算
+	var list []*model.Article
+	if err = s.db.Model(&list).Preload("Tags").
+		Select("id, created_at, title, color, urlname, urltype, cover, left(content, ?) as content", relatedExcerptLen).
+		Find(&list, "status = 0 and (visibility = ? or id = ?)", model.VisibilityPublic, id).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	docs := make([]*recommend.Doc, 0, len(list))
+	index := make(map[int64]*model.Article, len(list))
+	for _, article := range list {
+		doc := &recommend.Doc{ID: article.ID}
+		// 标题权重高于正文
+		doc.Text = strings.Repeat(article.Title+"\n", 3) + article.Content
+		for _, tag := range article.Tags {
+			doc.Tags = append(doc.Tags, tag.Name)
+		}
+		docs = append(docs, doc)
+		index[article.ID] = article
+	}
+	articles = make([]*model.Article, 0, limit)
+	for _, score := range recommend.Related(id, docs, limit) {
+		article := index[score.ID]
+		article.Content = ""
+		article.Tags = nil
+		articles = append(articles, article)
+	}
+	if err = s.mc.Set(key, &articles); err != nil {
+		return nil, s.hm.GetMessage(1002, err)
+	}
+	return
+}
+
+// 相关文章缓存版本号，文章变更时更新
+func (s *service) relatedVersion() (version int64) {
+	key := s.hm.GetCacheKey(11)
+	if err := s.mc.Get(key, &version); err != nil {
+		version = time.Now().UnixNano()
+		s.mc.Set(key, &version)
+	}
+	return
+}
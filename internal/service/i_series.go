@@ -0,0 +1,193 @@
+package service
+
+import (
+	"ginana-blog/internal/model"
+	"github.com/jinzhu/gorm"
+)
+
+func (s *service) GetSeriesList(p *model.Pager) (res *model.SeriesList, err error) {
+	res = new(model.SeriesList)
+	query := s.db.Model(&res.List).Count(&p.AllCount).Order("id desc")
+	if err = query.Limit(p.PageSize).Offset((p.Page - 1) * p.PageSize).Find(&res.List).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	for _, series := range res.List {
+		if err = s.loadSeriesArticles(series, false); err != nil {
+			return nil, err
+		}
+	}
+	res.Pager = p
+	return
+}
+
+func (s *service) GetSeries(id int64) (series *model.Series, err error) {
+	return s.getSeries(id, false)
+}
+
+// GetPublicSeries 前台展示的系列，只含已发布的公开文章
+func (s *service) GetPublicSeries(id int64) (series *model.Series, err error) {
+	return s.getSeries(id, true)
+}
+
+func (s *service) getSeries(id int64, public bool) (series *model.Series, err error) {
+	series = new(model.Series)
+	if err = s.db.Find(series, "id = ?", id).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	if err = s.loadSeriesArticles(series, public); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (s *service) CreateSeries(req *model.CreateSeriesReq) (series *model.Series, err error) {
+	series = new(model.Series)
+	series.Title = req.Title
+	series.Description = req.Description
+	series.Cover = req.Cover
+	tx := s.db.Begin()
+	if err = tx.Create(series).Error; err != nil {
+		tx.Rollback()
+		return nil, s.hm.GetMessage(1002, err)
+	}
+	if err = s.setSeriesArticles(tx, series.ID, req.ArticleIDs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	tx.Commit()
+	return
+}
+
+func (s *service) UpdateSeries(req *model.UpdateSeriesReq) (series *model.Series, err error) {
+	series = new(model.Series)
+	if err = s.db.Find(series, "id = ?", req.ID).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	series.Title = req.Title
+	series.Description = req.Description
+	series.Cover = req.Cover
+	m, err := s.tool.StructToMap(series)
+	if err != nil {
+		return nil, s.hm.GetMessage(500, err)
+	}
+	tx := s.db.Begin()
+	if err = tx.Model(series).Update(m).Error; err != nil {
+		tx.Rollback()
+		return nil, s.hm.GetMessage(1003, err)
+	}
+	if err = s.setSeriesArticles(tx, series.ID, req.ArticleIDs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	tx.Commit()
+	return
+}
+
+func (s *service) DeleteSeries(id int64) (err error) {
+	tx := s.db.Begin()
+	if err = tx.Delete(&model.SeriesArticle{}, "series_id = ?", id).Error; err != nil {
+		tx.Rollback()
+		return s.hm.GetMessage(1004, err)
+	}
+	if err = tx.Delete(&model.Series{}, "id = ?", id).Error; err != nil {
+		tx.Rollback()
+		return s.hm.GetMessage(1004, err)
+	}
+	tx.Commit()
+	return
+}
+
+// GetArticleTitles 全部文章的ID、标题与状态，用于后台选择文章
+func (s *service) GetArticleTitles() (articles []*model.Article, err error) {
+	if err = s.db.Select("id, title, status").Order("id desc").Find(&articles).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	return
+}
+
+// 按顺序载入系列中的文章，不含正文，public 为真时只载入已发布的公开文章
+func (s *service) loadSeriesArticles(series *model.Series, public bool) (err error) {
+	var links []*model.SeriesArticle
+	if err = s.db.Order("sort asc, id asc").Find(&links, "series_id = ?", series.ID).Error; err != nil {
+		return s.hm.GetMessage(1001, err)
+	}
+	if len(links) == 0 {
+		return
+	}
+	ids := make([]int64, 0, len(links))
+	for _, link := range links {
+		ids = append(ids, link.ArticleID)
+	}
+	var articles []*model.Article
+	query := s.db.Select("id, created_at, updated_at, title, color, urlname, urltype, status, cover, views, likes, words, read_time")
+	if public {
+		query = query.Where("status = 0 and visibility = ?", model.VisibilityPublic)
+	}
+	if err = query.Find(&articles, "id in (?)", ids).Error; err != nil {
+		return s.hm.GetMessage(1001, err)
+	}
+	index := make(map[int64]*model.Article, len(articles))
+	for _, a := range articles {
+		index[a.ID] = a
+	}
+	series.Articles = make([]*model.Article, 0, len(articles))
+	for _, id := range ids {
+		if a, ok := index[id]; ok {
+			series.Articles = append(series.Articles, a)
+		}
+	}
+	return
+}
+
+// 重建系列的文章顺序，已属于其他系列的文章会被移入本系列
+func (s *service) setSeriesArticles(tx *gorm.DB, seriesID int64, articleIDs string) (err error) {
+	ids := model.ParseIDs(articleIDs)
+	if err = tx.Delete(&model.SeriesArticle{}, "series_id = ?", seriesID).Error; err != nil {
+		return s.hm.GetMessage(1004, err)
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if err = tx.Delete(&model.SeriesArticle{}, "article_id in (?)", ids).Error; err != nil {
+		return s.hm.GetMessage(1004, err)
+	}
+	for i, id := range ids {
+		link := &model.SeriesArticle{SeriesID: seriesID, ArticleID: id, Sort: i + 1}
+		if err = tx.Create(link).Error; err != nil {
+			return s.hm.GetMessage(1002, err)
+		}
+	}
+	return
+}
+
+// 文章所在系列的导航，只计算已发布的文章
+func (s *service) setArticleSeries(article *model.Article) (err error) {
+	link := new(model.SeriesArticle)
+	err = s.db.Find(link, "article_id = ?", article.ID).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return s.hm.GetMessage(1001, err)
+	}
+	series, err := s.GetPublicSeries(link.SeriesID)
+	if err != nil {
+		return
+	}
+	list := series.Articles
+	nav := &model.SeriesNav{Series: series, Total: len(list)}
+	for i, a := range list {
+		if a.ID != article.ID {
+			continue
+		}
+		nav.Position = i + 1
+		if i > 0 {
+			nav.Prev = list[i-1]
+		}
+		if i < len(list)-1 {
+			nav.Next = list[i+1]
+		}
+	}
+	article.Series = nav
+	return
+}
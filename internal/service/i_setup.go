@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"ginana-blog/internal/model"
+	"github.com/jinzhu/gorm"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// NeedSetup 是否尚未有任何用户持有超级管理员角色，已软删除的用户同样计入，
+// 避免管理员被删除后安装向导重新对外开放。完成安装后结果常驻内存
+func (s *service) NeedSetup() bool {
+	if atomic.LoadInt32(s.installed) == 1 {
+		return false
+	}
+	count, err := s.countSuperAdmins()
+	if err != nil || count > 0 {
+		atomic.StoreInt32(s.installed, 1)
+		return false
+	}
+	return true
+}
+
+// countSuperAdmins 持有超级管理员角色的用户数，包括软删除的用户
+func (s *service) countSuperAdmins() (count int64, err error) {
+	un := s.db.NewScope(&model.User{}).TableName()
+	err = s.db.Table("user_roles").
+		Joins(fmt.Sprintf("JOIN %s ON %s.id = user_roles.user_id", un, un)).
+		Where("user_roles.role_id = 1").Count(&count).Error
+	return
+}
+
+// Setup 首次安装：创建拥有超级管理员角色的管理员并写入站点设置，已有管理员时拒绝执行
+func (s *service) Setup(req *model.SetupReq) (user *model.User, err error) {
+	if !s.NeedSetup() {
+		return nil, s.hm.GetMessage(1002, "站点已完成安装")
+	}
+	// 种子文件不经过表单校验
+	if req.Username == "" || len(req.Password) < 6 || req.SiteName == "" {
+		return nil, s.hm.GetMessage(1002, "管理员帐号、不少于6位的密码与站点名称为必填项")
+	}
+	options := make(map[string]string)
+	ot := reflect.TypeOf(model.Option{})
+	for name, value := range req.Options {
+		if _, ok := ot.FieldByName(name); !ok {
+			return nil, s.hm.GetMessage(1002, fmt.Sprintf("未知的站点设置：%s", name))
+		}
+		options[name] = value
+	}
+	options["SiteName"] = req.SiteName
+	options["SiteURL"] = strings.TrimRight(req.SiteURL, "/")
+	if req.Theme != "" {
+		options["Theme"] = req.Theme
+	}
+	if req.Nickname == "" {
+		req.Nickname = req.Username
+	}
+	if _, ok := options["Nickname"]; !ok {
+		options["Nickname"] = req.Nickname
+	}
+	tx := s.db.Begin()
+	if user, err = s.withDB(tx).setup(req, options); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, s.hm.GetMessage(1002, err)
+	}
+	atomic.StoreInt32(s.installed, 1)
+	s.mc.Delete(s.hm.GetCacheKey(1, user.ID))
+	s.mc.Delete(s.hm.GetCacheKey(3))
+	if s.ef != nil {
+		_ = s.ef.LoadPolicy()
+	}
+	return
+}
+
+func (s *service) setup(req *model.SetupReq, options map[string]string) (user *model.User, err error) {
+	role := new(model.Role)
+	if err = s.db.Find(role, "id = 1").Error; err == gorm.ErrRecordNotFound {
+		role.RoleName = "super_admin"
+		err = s.db.Create(role).Error
+	}
+	if err != nil {
+		return nil, s.hm.GetMessage(1002, err)
+	}
+	// 锁住超级管理员角色，并发的安装请求在此排队，之后重新检查是否已有管理员
+	if err = s.db.Set("gorm:query_option", "FOR UPDATE").Find(role, "id = 1").Error; err != nil {
+		return nil, s.hm.GetMessage(1002, err)
+	}
+	count, err := s.countSuperAdmins()
+	if err != nil {
+		return nil, s.hm.GetMessage(1002, err)
+	}
+	if count > 0 {
+		return nil, s.hm.GetMessage(1002, "站点已完成安装")
+	}
+	// 权限判断以 ID 为1的用户作为站长。该用户已存在但没有超级管理员角色时（如旧版升级），
+	// 改为本次填写的帐号信息并补上角色，而不是再插入一个用户
+	user = new(model.User)
+	if err = s.db.Unscoped().Find(user, "id = 1").Error; err != nil && err != gorm.ErrRecordNotFound {
+		return nil, s.hm.GetMessage(1002, err)
+	}
+	password := s.tool.BcryptHashGenerate(req.Password)
+	if err == gorm.ErrRecordNotFound {
+		user = &model.User{
+			ID:       1,
+			Username: req.Username,
+			Password: password,
+			Nickname: req.Nickname,
+			Email:    req.Email,
+			IsAuth:   true,
+			Roles:    []*model.Role{role},
+		}
+		if err = s.db.Create(user).Error; err != nil {
+			return nil, s.hm.GetMessage(1002, err)
+		}
+	} else {
+		if err = s.db.Unscoped().Model(user).Updates(map[string]interface{}{
+			"username":   req.Username,
+			"password":   password,
+			"nickname":   req.Nickname,
+			"email":      req.Email,
+			"is_auth":    true,
+			"deleted_at": nil,
+		}).Error; err != nil {
+			return nil, s.hm.GetMessage(1003, err)
+		}
+		if err = s.db.Model(user).Association("Roles").Append(role).Error; err != nil {
+			return nil, s.hm.GetMessage(1002, err)
+		}
+	}
+	for name, value := range options {
+		if err = s.db.Where(model.Options{Name: name}).Assign(model.Options{Value: value}).
+			FirstOrCreate(new(model.Options)).Error; err != nil {
+			return nil, s.hm.GetMessage(1003, err)
+		}
+	}
+	return
+}
@@ -46,8 +46,9 @@ func (s *service) UpdateSiteOptions(req *model.Option) (err error) {
 		for j := 0; j < rt.NumField(); j++ {
 			value := rv.Field(j).Interface()
 			if name == rt.Field(j).Name && ov.Field(i).Interface() != value {
-				if err = s.db.Model(new(model.Options)).Where("name = ?", name).
-					Update("value", value).Error; err != nil {
+				// 新增的设置项在旧库中没有记录，不存在时创建
+				if err = s.db.Where(model.Options{Name: name}).Assign(model.Options{Value: value.(string)}).
+					FirstOrCreate(new(model.Options)).Error; err != nil {
 					tx.Rollback()
 					return s.hm.GetMessage(1003, err)
 				}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"fmt"
+	"ginana-blog/internal/model"
+	"github.com/mozillazg/go-pinyin"
+	"strings"
+	"unicode"
+)
+
+// URL名称的最大字节数，手动填写与自动生成共用，不超过 urlname 列宽
+const maxSlugLen = 100
+
+// 前台已占用的路径，文章URL名称的首段不能与之相同
+var reservedSlugs = map[string]bool{
+	"about.html": true, "life.html": true, "category.html": true, "mood.html": true,
+	"links.html": true, "album.html": true, "feed.xml": true, "sitemap.xml": true,
+	"index.html": true, "favicon.ico": true, "robots.txt": true, "archives": true,
+	"article": true, "category": true, "tag": true, "c": true, "s": true, "series": true,
+	"album": true, "admin": true, "api": true, "public": true, "static": true, "swagger": true,
+}
+
+var slugPinyinArgs = pinyin.NewArgs()
+
+// Slugify 由标题生成URL名称，汉字转为不带声调的拼音，其余非字母数字作为分隔
+func Slugify(title string) string {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(model.NormalizeTagName(title)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(r)
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if py := pinyin.SinglePinyin(r, slugPinyinArgs); len(py) > 0 {
+				words = append(words, py[0])
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+	slug := ""
+	for _, w := range words {
+		if len(slug)+len(w)+1 > maxSlugLen {
+			break
+		}
+		if slug != "" {
+			slug += "-"
+		}
+		slug += w
+	}
+	return slug
+}
+
+// ArticleSlug 校验文章URL名称，为空且作为独立页面时由标题生成，重名时追加序号
+func (s *service) ArticleSlug(title, urlname string, urltype int8, id int64) (slug string, err error) {
+	slug = strings.Trim(strings.TrimSpace(urlname), "/")
+	slug = strings.Join(strings.Fields(slug), "-")
+	if slug == "" {
+		if urltype != 1 {
+			return "", nil
+		}
+		base := Slugify(title)
+		if base == "" {
+			return "", s.hm.GetMessage(500, "无法由标题生成URL名称，请手动填写")
+		}
+		return s.uniqueSlug(base, id)
+	}
+	if strings.ContainsAny(slug, "?#%\\") {
+		return "", s.hm.GetMessage(500, "URL名称不能包含 ? # % \\ 等字符")
+	}
+	if len(slug) > maxSlugLen {
+		return "", s.hm.GetMessage(500, fmt.Sprintf("URL名称不能超过%d个字符", maxSlugLen))
+	}
+	return slug, s.checkArticleSlug(slug, id)
+}
+
+// checkArticleSlug URL名称不能是前台保留路径，也不能与其他文章重复
+func (s *service) checkArticleSlug(slug string, id int64) (err error) {
+	if reservedSlug(slug) {
+		return s.hm.GetMessage(500, fmt.Sprintf("URL名称「%s」为系统保留路径", slug))
+	}
+	taken, err := s.slugTaken(slug, id)
+	if err != nil {
+		return
+	}
+	if taken {
+		return s.hm.GetMessage(500, fmt.Sprintf("URL名称「%s」已被其他文章使用", slug))
+	}
+	return
+}
+
+func reservedSlug(slug string) bool {
+	return reservedSlugs[strings.ToLower(strings.SplitN(slug, "/", 2)[0])]
+}
+
+// slugTaken URL名称是否已被其他文章使用
+func (s *service) slugTaken(slug string, id int64) (taken bool, err error) {
+	var count int64
+	if err = s.db.Model(&model.Article{}).Where("urlname = ? and id <> ?", slug, id).
+		Count(&count).Error; err != nil {
+		return false, s.hm.GetMessage(1001, err)
+	}
+	return count > 0, nil
+}
+
+// uniqueSlug 以 base 为基础，重名或为保留路径时追加序号
+func (s *service) uniqueSlug(base string, id int64) (slug string, err error) {
+	slug = base
+	for i := 2; ; i++ {
+		if !reservedSlug(slug) {
+			taken, err := s.slugTaken(slug, id)
+			if err != nil || !taken {
+				return slug, err
+			}
+		}
+		if i > 100 {
+			return "", s.hm.GetMessage(500, "无法生成唯一的URL名称，请手动填写")
+		}
+		suffix := fmt.Sprintf("-%d", i)
+		slug = strings.TrimRight(cutSlug(base, maxSlugLen-len(suffix)), "-") + suffix
+	}
+}
+
+// cutSlug 截取不超过 n 字节的前缀，不截断多字节字符
+func cutSlug(slug string, n int) string {
+	if len(slug) <= n {
+		return slug
+	}
+	end := 0
+	for i := range slug {
+		if i > n {
+			break
+		}
+		end = i
+	}
+	return slug[:end]
+}
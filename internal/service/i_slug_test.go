@@ -0,0 +1,26 @@
+package service
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	Convey("Slugify", t, func() {
+		So(Slugify("Hello, World!"), ShouldEqual, "hello-world")
+		So(Slugify("Go语言入门"), ShouldEqual, "go-yu-yan-ru-men")
+		So(Slugify("ＭｙＳＱＬ 索引 2020"), ShouldEqual, "mysql-suo-yin-2020")
+		So(Slugify("！？"), ShouldBeEmpty)
+		So(len(Slugify(strings.Repeat("很长的标题", 20))), ShouldBeLessThanOrEqualTo, maxSlugLen)
+	})
+}
+
+func TestCutSlug(t *testing.T) {
+	Convey("cutSlug", t, func() {
+		So(cutSlug("hello", 10), ShouldEqual, "hello")
+		So(cutSlug("hello-world", 5), ShouldEqual, "hello")
+		So(cutSlug("中文", 4), ShouldEqual, "中")
+		So(cutSlug("中文", 3), ShouldEqual, "中")
+	})
+}
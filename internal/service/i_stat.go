@@ -0,0 +1,342 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"ginana-blog/internal/model"
+	"github.com/griffin702/ginana/library/log"
+	"github.com/jinzhu/gorm"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	statDateLayout = "2006-01-02"
+	statMaxDays    = 366 // 报表最长跨度
+	statRankSize   = 10
+	statNameSize   = 200 // 与StatItem.Name列宽一致
+	statMaxNames   = 500 // 由客户端决定名称的统计项，每类每天最多记录的不同名称数
+	statOtherName  = "(other)"
+)
+
+var (
+	// 名称由请求路径或来源决定的统计项，超出 statMaxNames 后归入 statOtherName，避免扫描器撑大统计表
+	statOpenKinds = map[string]bool{
+		model.StatKindPath:     true,
+		model.StatKindReferrer: true,
+		model.StatKindNotFound: true,
+	}
+
+	tabletUA = regexp.MustCompile(`(?i)ipad|tablet|kindle|silk|playbook`)
+	mobileUA = regexp.MustCompile(`(?i)mobile|iphone|ipod|android|phone|blackberry|opera mini|windows ce`)
+
+	deviceTitles = map[string]string{"mobile": "手机", "tablet": "平板", "desktop": "电脑"}
+)
+
+// DeviceClass 根据UA判断设备类型：mobile、tablet、desktop
+func DeviceClass(ua string) string {
+	switch {
+	case tabletUA.MatchString(ua):
+		return "tablet"
+	case mobileUA.MatchString(ua):
+		// 不带Mobile标识的安卓设备多为平板
+		if strings.Contains(strings.ToLower(ua), "android") && !strings.Contains(strings.ToLower(ua), "mobile") {
+			return "tablet"
+		}
+		return "mobile"
+	}
+	return "desktop"
+}
+
+type statKey struct {
+	date, kind, name string
+}
+
+// 访问统计，先在内存中汇总，定时写入按天汇总的统计表
+type statCollector struct {
+	mu       sync.Mutex
+	day      string
+	salt     []byte
+	visitors map[string]bool
+	daily    map[string]*model.StatDaily
+	items    map[statKey]int64
+	names    map[statKey]map[string]bool // 当天各类已记录的名称，键的 name 为空
+}
+
+func newStatCollector() *statCollector {
+	return &statCollector{
+		visitors: make(map[string]bool),
+		daily:    make(map[string]*model.StatDaily),
+		items:    make(map[statKey]int64),
+		names:    make(map[statKey]map[string]bool),
+	}
+}
+
+// 访客标识为IP与UA加当日随机盐的哈希，盐只保存在内存中且每天更换，无法还原出访客
+func (c *statCollector) visitor(day, ip, ua string) string {
+	if day != c.day {
+		c.day = day
+		c.salt = make([]byte, 16)
+		_, _ = rand.Read(c.salt)
+		c.visitors = make(map[string]bool)
+	}
+	h := sha256.New()
+	h.Write(c.salt)
+	h.Write([]byte(ip + "|" + ua))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *statCollector) add(date, kind, name string) {
+	if name == "" {
+		return
+	}
+	if r := []rune(name); len(r) > statNameSize {
+		name = string(r[:statNameSize])
+	}
+	if statOpenKinds[kind] {
+		name = c.limitName(date, kind, name)
+	}
+	c.items[statKey{date, kind, name}]++
+}
+
+// limitName 当天该类已有 statMaxNames 个不同名称时，新的名称归入 statOtherName
+func (c *statCollector) limitName(date, kind, name string) string {
+	key := statKey{date: date, kind: kind}
+	seen, ok := c.names[key]
+	if !ok {
+		for k := range c.names {
+			if k.date != date {
+				delete(c.names, k)
+			}
+		}
+		seen = make(map[string]bool)
+		c.names[key] = seen
+	}
+	if seen[name] {
+		return name
+	}
+	if len(seen) >= statMaxNames {
+		return statOtherName
+	}
+	seen[name] = true
+	return name
+}
+
+func (c *statCollector) record(hit *model.StatHit, now time.Time) {
+	date := now.Format(statDateLayout)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hit.Status == 404 {
+		c.add(date, model.StatKindNotFound, hit.Path)
+		return
+	}
+	if hit.Status >= 400 {
+		return
+	}
+	daily, ok := c.daily[date]
+	if !ok {
+		daily = &model.StatDaily{Date: date}
+		c.daily[date] = daily
+	}
+	daily.PageViews++
+	if v := c.visitor(date, hit.IP, hit.UserAgent); !c.visitors[v] {
+		c.visitors[v] = true
+		daily.Visitors++
+	}
+	c.add(date, model.StatKindPath, hit.Path)
+	c.add(date, model.StatKindDevice, DeviceClass(hit.UserAgent))
+	if u, err := url.Parse(hit.Referrer); err == nil && u.Host != "" && !strings.EqualFold(u.Host, hit.Host) {
+		c.add(date, model.StatKindReferrer, strings.ToLower(u.Host))
+	}
+	if hit.ArticleID > 0 {
+		c.add(date, model.StatKindArticle, strconv.FormatInt(hit.ArticleID, 10))
+	}
+	for _, id := range hit.TagIDs {
+		c.add(date, model.StatKindTag, strconv.FormatInt(id, 10))
+	}
+}
+
+func (c *statCollector) take() (daily map[string]*model.StatDaily, items map[statKey]int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	daily, items = c.daily, c.items
+	c.daily = make(map[string]*model.StatDaily)
+	c.items = make(map[statKey]int64)
+	return
+}
+
+// 写库失败时归还计数，等待下次写入
+func (c *statCollector) restore(daily *model.StatDaily, key *statKey, n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if daily != nil {
+		d, ok := c.daily[daily.Date]
+		if !ok {
+			d = &model.StatDaily{Date: daily.Date}
+			c.daily[daily.Date] = d
+		}
+		d.PageViews += daily.PageViews
+		d.Visitors += daily.Visitors
+	}
+	if key != nil {
+		c.items[*key] += n
+	}
+}
+
+// RecordHit 记录一次页面访问，爬虫不计入
+func (s *service) RecordHit(hit *model.StatHit) {
+	if IsBot(hit.UserAgent) {
+		return
+	}
+	s.stats.record(hit, time.Now())
+}
+
+func (s *service) flushStats() {
+	daily, items := s.stats.take()
+	for _, d := range daily {
+		res := s.db.Model(&model.StatDaily{}).Where("date = ?", d.Date).UpdateColumns(map[string]interface{}{
+			"page_views": gorm.Expr("page_views + ?", d.PageViews),
+			"visitors":   gorm.Expr("visitors + ?", d.Visitors),
+		})
+		if res.Error == nil && res.RowsAffected == 0 {
+			res = s.db.Create(&model.StatDaily{Date: d.Date, PageViews: d.PageViews, Visitors: d.Visitors})
+		}
+		if res.Error != nil {
+			log.Errorf("flush daily stat(%s) error(%v)", d.Date, res.Error)
+			s.stats.restore(d, nil, 0)
+		}
+	}
+	for key, n := range items {
+		res := s.db.Model(&model.StatItem{}).Where("date = ? and kind = ? and name = ?", key.date, key.kind, key.name).
+			UpdateColumn("count", gorm.Expr("count + ?", n))
+		if res.Error == nil && res.RowsAffected == 0 {
+			res = s.db.Create(&model.StatItem{Date: key.date, Kind: key.kind, Name: key.name, Count: n})
+		}
+		if res.Error != nil {
+			log.Errorf("flush stat item(%s %s) error(%v)", key.kind, key.name, res.Error)
+			k := key
+			s.stats.restore(nil, &k, n)
+		}
+	}
+}
+
+// GetStatReport 统计报表，默认最近30天
+func (s *service) GetStatReport(pr model.StatQueryParam) (res *model.StatReport, err error) {
+	end, err := time.ParseInLocation(statDateLayout, pr.End, time.Local)
+	if err != nil {
+		end, _ = time.ParseInLocation(statDateLayout, time.Now().Format(statDateLayout), time.Local)
+	}
+	start, err := time.ParseInLocation(statDateLayout, pr.Start, time.Local)
+	if err != nil {
+		start = end.AddDate(0, 0, -29)
+	}
+	if start.After(end) {
+		start, end = end, start
+	}
+	if end.Sub(start) > statMaxDays*24*time.Hour {
+		start = end.AddDate(0, 0, -statMaxDays+1)
+	}
+	res = new(model.StatReport)
+	res.Start = start.Format(statDateLayout)
+	res.End = end.Format(statDateLayout)
+	var list []*model.StatDaily
+	if err = s.db.Find(&list, "date between ? and ?", res.Start, res.End).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	days := make(map[string]*model.StatDaily, len(list))
+	for _, d := range list {
+		days[d.Date] = d
+	}
+	var max int64
+	for t := start; !t.After(end); t = t.AddDate(0, 0, 1) {
+		date := t.Format(statDateLayout)
+		d, ok := days[date]
+		if !ok {
+			d = &model.StatDaily{Date: date}
+		}
+		res.PageViews += d.PageViews
+		res.Visitors += d.Visitors
+		if d.PageViews > max {
+			max = d.PageViews
+		}
+		res.Days = append(res.Days, d)
+	}
+	for _, d := range res.Days {
+		if max > 0 {
+			d.Percent = int(d.PageViews * 100 / max)
+		}
+	}
+	if res.Paths, err = s.statRanks(model.StatKindPath, res); err != nil {
+		return nil, err
+	}
+	if res.Referrers, err = s.statRanks(model.StatKindReferrer, res); err != nil {
+		return nil, err
+	}
+	if res.Articles, err = s.statRanks(model.StatKindArticle, res); err != nil {
+		return nil, err
+	}
+	if res.Tags, err = s.statRanks(model.StatKindTag, res); err != nil {
+		return nil, err
+	}
+	if res.NotFound, err = s.statRanks(model.StatKindNotFound, res); err != nil {
+		return nil, err
+	}
+	if res.Devices, err = s.statRanks(model.StatKindDevice, res); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// 某一类统计项的排行，并补全显示名称
+func (s *service) statRanks(kind string, report *model.StatReport) (list []*model.StatRank, err error) {
+	if err = s.db.Model(&model.StatItem{}).Select("name, sum(count) as count").
+		Where("kind = ? and date between ? and ?", kind, report.Start, report.End).
+		Group("name").Order("count desc").Limit(statRankSize).Scan(&list).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	if len(list) == 0 {
+		return
+	}
+	titles := make(map[string]string)
+	var ids []string
+	for _, r := range list {
+		ids = append(ids, r.Name)
+	}
+	switch kind {
+	case model.StatKindArticle:
+		var articles []*model.Article
+		if err = s.db.Select("id, title").Find(&articles, "id in (?)", ids).Error; err != nil {
+			return nil, s.hm.GetMessage(1001, err)
+		}
+		for _, a := range articles {
+			titles[strconv.FormatInt(a.ID, 10)] = a.Title
+		}
+	case model.StatKindTag:
+		var tags []*model.Tag
+		if err = s.db.Select("id, name").Find(&tags, "id in (?)", ids).Error; err != nil {
+			return nil, s.hm.GetMessage(1001, err)
+		}
+		for _, t := range tags {
+			titles[strconv.FormatInt(t.ID, 10)] = t.Name
+		}
+	case model.StatKindDevice:
+		titles = deviceTitles
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Count > list[j].Count })
+	for _, r := range list {
+		r.Title = r.Name
+		if t, ok := titles[r.Name]; ok {
+			r.Title = t
+		}
+		if list[0].Count > 0 {
+			r.Percent = int(r.Count * 100 / list[0].Count)
+		}
+	}
+	return
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"ginana-blog/internal/model"
+	. "github.com/smartystreets/goconvey/convey"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStatCollector(t *testing.T) {
+	Convey("statCollector", t, func() {
+		c := newStatCollector()
+		now := time.Date(2020, 3, 1, 10, 0, 0, 0, time.Local)
+		ua := "Mozilla/5.0 (iPhone; CPU iPhone OS 13_3 like Mac OS X) Mobile/15E148"
+		c.record(&model.StatHit{Path: "/article/1", Host: "inana.top", IP: "1.1.1.1", UserAgent: ua,
+			Status: 200, Referrer: "https://www.baidu.com/s?wd=go", ArticleID: 1, TagIDs: []int64{2}}, now)
+		c.record(&model.StatHit{Path: "/", Host: "inana.top", IP: "1.1.1.1", UserAgent: ua,
+			Status: 200, Referrer: "https://inana.top/article/1"}, now)
+		c.record(&model.StatHit{Path: "/nothing", IP: "2.2.2.2", UserAgent: ua, Status: 404}, now)
+		daily, items := c.take()
+		So(daily["2020-03-01"].PageViews, ShouldEqual, 2)
+		So(daily["2020-03-01"].Visitors, ShouldEqual, 1)
+		So(items[statKey{"2020-03-01", model.StatKindReferrer, "www.baidu.com"}], ShouldEqual, 1)
+		So(items[statKey{"2020-03-01", model.StatKindDevice, "mobile"}], ShouldEqual, 2)
+		So(items[statKey{"2020-03-01", model.StatKindArticle, "1"}], ShouldEqual, 1)
+		So(items[statKey{"2020-03-01", model.StatKindTag, "2"}], ShouldEqual, 1)
+		So(items[statKey{"2020-03-01", model.StatKindNotFound, "/nothing"}], ShouldEqual, 1)
+		So(len(items), ShouldEqual, 7)
+
+		for i := 0; i < statMaxNames+10; i++ {
+			c.record(&model.StatHit{Path: "/scan/" + strconv.Itoa(i), Status: 404}, now)
+		}
+		c.record(&model.StatHit{Path: "/nothing", Status: 404}, now)
+		_, items = c.take()
+		So(len(items), ShouldEqual, statMaxNames+1)
+		So(items[statKey{"2020-03-01", model.StatKindNotFound, statOtherName}], ShouldEqual, 11)
+		So(items[statKey{"2020-03-01", model.StatKindNotFound, "/nothing"}], ShouldEqual, 1)
+	})
+	Convey("DeviceClass", t, func() {
+		So(DeviceClass("Mozilla/5.0 (iPad; CPU OS 13_3 like Mac OS X)"), ShouldEqual, "tablet")
+		So(DeviceClass("Mozilla/5.0 (Linux; Android 10; SM-T510)"), ShouldEqual, "tablet")
+		So(DeviceClass("Mozilla/5.0 (Linux; Android 10; Pixel 3) Mobile Safari/537.36"), ShouldEqual, "mobile")
+		So(DeviceClass("Mozilla/5.0 (Windows NT 10.0; Win64; x64)"), ShouldEqual, "desktop")
+	})
+}
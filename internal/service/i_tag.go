@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"ginana-blog/internal/model"
 	"github.com/jinzhu/gorm"
+	"strings"
 )
 
 func (s *service) GetTags(p *model.Pager, prs ...model.TagQueryParam) (res *model.Tags, err error) {
@@ -39,18 +40,121 @@ func (s *service) CountTags() (count int64) {
 	return
 }
 
+func (s *service) GetTag(id int64) (tag *model.Tag, err error) {
+	tag = new(model.Tag)
+	if err = s.db.Preload("Aliases").Find(tag, "id = ?", id).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	return
+}
+
+// GetTagByName 按名称查找标签，忽略大小写与全半角差异，并解析同义词
 func (s *service) GetTagByName(name string) (tag *model.Tag, err error) {
+	key := model.TagKey(name)
 	tag = new(model.Tag)
-	if err = s.db.Find(tag, "name = ?", name).Error; err != nil {
+	if err = s.db.Find(tag, "lower(name) = ?", key).Error; err == gorm.ErrRecordNotFound {
+		alias := new(model.TagAlias)
+		if err = s.db.Find(alias, "name = ?", key).Error; err == nil {
+			err = s.db.Find(tag, "id = ?", alias.TagID).Error
+		}
+	}
+	if err != nil {
 		return nil, s.hm.GetMessage(1001, err)
 	}
 	return
 }
 
+// ResolveTag 依次按URL别名、名称、同义词解析标签
+func (s *service) ResolveTag(key string) (tag *model.Tag, err error) {
+	tag = new(model.Tag)
+	slug := strings.ToLower(strings.TrimSpace(key))
+	if slug != "" && s.db.Find(tag, "slug = ?", slug).Error == nil {
+		return
+	}
+	return s.GetTagByName(key)
+}
+
+// ResolveTagNames 将逗号分隔的标签名替换为规范名称，未知标签保留规范化后的原名
+func (s *service) ResolveTagNames(names string) (list []string) {
+	seen := make(map[string]bool)
+	for _, name := range model.SplitTagNames(names) {
+		if tag, err := s.GetTagByName(name); err == nil {
+			name = tag.Name
+		}
+		if key := model.TagKey(name); !seen[key] {
+			seen[key] = true
+			list = append(list, name)
+		}
+	}
+	return
+}
+
+func (s *service) UpdateTag(req *model.UpdateTagReq) (tag *model.Tag, err error) {
+	tag = new(model.Tag)
+	if err = s.db.Find(tag, "id = ?", req.ID).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	tag.Name = model.NormalizeTagName(req.Name)
+	if tag.Name == "" {
+		return nil, s.hm.GetMessage(1003, "标签名称不能为空")
+	}
+	if t, e := s.GetTagByName(tag.Name); e == nil && t.ID != tag.ID {
+		return nil, s.hm.GetMessage(1003, fmt.Sprintf("标签名称与「%s」冲突", t.Name))
+	}
+	if strings.TrimSpace(req.Slug) == "" {
+		tag.Slug = s.tagSlug(tag.ID, tag.Name)
+	} else {
+		var ok bool
+		if tag.Slug, ok = makeSlug(req.Slug, ""); !ok {
+			return nil, s.hm.GetMessage(1003, "URL别名只能包含小写字母、数字与连字符")
+		}
+		if s.tagSlug(tag.ID, tag.Slug) == "" {
+			return nil, s.hm.GetMessage(1003, "URL别名已被其他标签使用")
+		}
+	}
+	tag.Description = req.Description
+	tag.Cover = req.Cover
+	var aliases []string
+	for _, name := range model.SplitTagNames(req.Aliases) {
+		key := model.TagKey(name)
+		if key == model.TagKey(tag.Name) {
+			continue
+		}
+		if t, e := s.GetTagByName(key); e == nil && t.ID != tag.ID {
+			return nil, s.hm.GetMessage(1003, fmt.Sprintf("同义词「%s」已属于标签「%s」", name, t.Name))
+		}
+		aliases = append(aliases, key)
+	}
+	tx := s.db.Begin()
+	if err = tx.Model(tag).Updates(map[string]interface{}{
+		"name":        tag.Name,
+		"slug":        tag.Slug,
+		"description": tag.Description,
+		"cover":       tag.Cover,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, s.hm.GetMessage(1003, err)
+	}
+	if err = tx.Delete(&model.TagAlias{}, "tag_id = ?", tag.ID).Error; err != nil {
+		tx.Rollback()
+		return nil, s.hm.GetMessage(1004, err)
+	}
+	for _, name := range aliases {
+		if err = tx.Create(&model.TagAlias{Name: name, TagID: tag.ID}).Error; err != nil {
+			tx.Rollback()
+			return nil, s.hm.GetMessage(1002, err)
+		}
+	}
+	tx.Commit()
+	s.deleteArticleCache()
+	return
+}
+
 func (s *service) BatchTag(req *model.TagListReq) (err error) {
 	switch req.Option {
 	case "merge":
-		if req.NewName == "" {
+		name := model.NormalizeTagName(req.NewName)
+		if name == "" {
 			return s.hm.GetMessage(500, "未输入新标签名")
 		}
 		var tags []*model.Tag
@@ -59,14 +163,15 @@ func (s *service) BatchTag(req *model.TagListReq) (err error) {
 			return s.hm.GetMessage(500, err)
 		}
 		tx := s.db.Begin()
-		tag, err := s.GetTagByName(req.NewName)
+		tag, err := s.GetTagByName(name)
 		if err == gorm.ErrRecordNotFound {
-			tag = new(model.Tag)
-			tag.Name = req.NewName
+			tag = s.newTag(name)
 			if err = tx.Create(tag).Error; err != nil {
+				tx.Rollback()
 				return s.hm.GetMessage(500, err)
 			}
 		} else if err != nil {
+			tx.Rollback()
 			return s.hm.GetMessage(500, err)
 		} else {
 			for i := 0; i < len(req.IDs); i++ {
@@ -76,17 +181,35 @@ func (s *service) BatchTag(req *model.TagListReq) (err error) {
 				}
 			}
 		}
+		// 被合并标签的名称与同义词均转为新标签的同义词
+		if err = tx.Model(&model.TagAlias{}).Where("tag_id in (?)", req.IDs).
+			Update("tag_id", tag.ID).Error; err != nil {
+			tx.Rollback()
+			return s.hm.GetMessage(1003, err)
+		}
+		for _, t := range tags {
+			key := model.TagKey(t.Name)
+			if t.ID == tag.ID || key == model.TagKey(tag.Name) {
+				continue
+			}
+			if err = tx.Where(model.TagAlias{Name: key}).Assign(model.TagAlias{TagID: tag.ID}).
+				FirstOrCreate(&model.TagAlias{}).Error; err != nil {
+				tx.Rollback()
+				return s.hm.GetMessage(1002, err)
+			}
+		}
+		if err = tx.Delete(&model.TagAlias{}, "name = ?", model.TagKey(tag.Name)).Error; err != nil {
+			tx.Rollback()
+			return s.hm.GetMessage(1004, err)
+		}
 		if err = tx.Delete(&model.Tag{}, "id in (?)", req.IDs).Error; err != nil {
 			tx.Rollback()
 			return s.hm.GetMessage(1004, err)
 		}
-		articles := make(map[int64]bool)
+		articles := make(map[int64]bool) // 文章是否已关联新标签
 		for _, t := range tags {
 			for _, a := range t.Articles {
-				articles[a.ID] = true
-				if t.ID != tag.ID {
-					articles[a.ID] = false
-				}
+				articles[a.ID] = articles[a.ID] || t.ID == tag.ID
 			}
 		}
 		for id, ok := range articles {
@@ -107,11 +230,19 @@ func (s *service) BatchTag(req *model.TagListReq) (err error) {
 		}
 		tx.Commit()
 	case "delete":
+		tx := s.db.Begin()
+		if err = tx.Delete(&model.TagAlias{}, "tag_id in (?)", req.IDs).Error; err != nil {
+			tx.Rollback()
+			return s.hm.GetMessage(1004, err)
+		}
 		tag := new(model.Tag)
-		if err = s.db.Model(tag).Where("id in (?)", req.IDs).Delete(tag).Error; err != nil {
+		if err = tx.Model(tag).Where("id in (?)", req.IDs).Delete(tag).Error; err != nil {
+			tx.Rollback()
 			return s.hm.GetMessage(1004, err)
 		}
+		tx.Commit()
 	}
+	s.deleteArticleCache()
 	return
 }
 
@@ -135,3 +266,53 @@ func (s *service) GetTagsLimit6() (tags []*model.Tag, err error) {
 	}
 	return
 }
+
+// articleTags 解析文章的标签列表，同义词归并到规范标签，不存在的标签新建。
+// 同一批新建的标签按 TagKey 去重，生成的URL别名相同时只保留第一个
+func (s *service) articleTags(names string) (tags []*model.Tag, err error) {
+	seen := make(map[int64]bool)
+	keys := make(map[string]bool)
+	slugs := make(map[string]bool)
+	for _, name := range model.SplitTagNames(names) {
+		var tag *model.Tag
+		if tag, err = s.GetTagByName(name); err == gorm.ErrRecordNotFound {
+			key := model.TagKey(name)
+			if keys[key] {
+				continue
+			}
+			keys[key] = true
+			tag = s.newTag(name)
+			if tag.Slug != "" && slugs[tag.Slug] {
+				tag.Slug = ""
+			}
+			slugs[tag.Slug] = true
+			tags = append(tags, tag)
+			continue
+		} else if err != nil {
+			return nil, s.hm.GetMessage(500, err)
+		}
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (s *service) newTag(name string) *model.Tag {
+	return &model.Tag{Name: name, Slug: s.tagSlug(0, name)}
+}
+
+// tagSlug 由名称生成URL别名，无法生成或已被其他标签占用时返回空
+func (s *service) tagSlug(id int64, name string) string {
+	slug, ok := makeSlug("", name)
+	if !ok {
+		return ""
+	}
+	var count int64
+	s.db.Model(&model.Tag{}).Where("slug = ? and id <> ?", slug, id).Count(&count)
+	if count > 0 {
+		return ""
+	}
+	return slug
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestArticleTags(t *testing.T) {
+	requireDB(t)
+	Convey("articleTags 同一批新标签去重", t, func() {
+		suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+		tags, err := svc.(*service).articleTags("T" + suffix + "+, t" + suffix + "+, t" + suffix + "#")
+		So(err, ShouldBeNil)
+		So(tags, ShouldHaveLength, 2)
+		So(tags[0].Name, ShouldEqual, "T"+suffix+"+")
+		So(tags[0].Slug, ShouldEqual, "t"+suffix)
+		So(tags[1].Slug, ShouldBeEmpty)
+	})
+}
@@ -193,3 +193,56 @@ func (s *service) DeleteUser(id int64) (err error) {
 	s.ef.LoadPolicy()
 	return
 }
+
+// ResetPassword 重置密码，供命令行找回管理员账号
+func (s *service) ResetPassword(username, password string) (user *model.User, err error) {
+	if user, err = s.GetUserByUsername(username); err != nil {
+		return
+	}
+	user.Password = s.tool.BcryptHashGenerate(password)
+	if err = s.db.Model(user).UpdateColumn("password", user.Password).Error; err != nil {
+		return nil, s.hm.GetMessage(1003, err)
+	}
+	s.mc.Delete(s.hm.GetCacheKey(1, user.ID))
+	return
+}
+
+// SetUserAuth 启用或禁用账号，未认证的账号不能登录
+func (s *service) SetUserAuth(username string, isAuth bool) (user *model.User, err error) {
+	if user, err = s.GetUserByUsername(username); err != nil {
+		return
+	}
+	user.IsAuth = isAuth
+	if err = s.db.Model(user).UpdateColumn("is_auth", isAuth).Error; err != nil {
+		return nil, s.hm.GetMessage(1003, err)
+	}
+	s.mc.Delete(s.hm.GetCacheKey(1, user.ID))
+	return
+}
+
+// GrantRole 为用户添加角色，已拥有时不重复添加
+func (s *service) GrantRole(username, roleName string) (err error) {
+	user, err := s.GetUserByUsername(username)
+	if err != nil {
+		return
+	}
+	role, err := s.GetRoleByName(roleName)
+	if err != nil {
+		return
+	}
+	var count int64
+	if err = s.db.Model(&model.UserRoles{}).Where("user_id = ? AND role_id = ?", user.ID, role.ID).
+		Count(&count).Error; err != nil {
+		return s.hm.GetMessage(1001, err)
+	}
+	if count == 0 {
+		if err = s.db.Create(&model.UserRoles{UserID: user.ID, RoleID: role.ID}).Error; err != nil {
+			return s.hm.GetMessage(1002, err)
+		}
+	}
+	s.mc.Delete(s.hm.GetCacheKey(1, user.ID))
+	if s.ef != nil {
+		_ = s.ef.LoadPolicy()
+	}
+	return
+}
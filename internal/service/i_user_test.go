@@ -7,6 +7,7 @@ import (
 )
 
 func TestCreateUser(t *testing.T) {
+	requireDB(t)
 	Convey("CreateUser", t, func() {
 		req := new(model.CreateUserReq)
 		req.Username = "admin"
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"ginana-blog/internal/model"
+	"github.com/griffin702/ginana/library/log"
+	"github.com/jinzhu/gorm"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	defViewFlush  = time.Minute      // 默认每分钟写库一次
+	defViewWindow = 30 * time.Minute // 默认同一访客30分钟内只计一次
+	maxViewSeen   = 100000           // 去重记录上限，超出时丢弃最早的记录
+)
+
+// 常见爬虫、监控及命令行工具的UA
+var botUA = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|curl|wget|python|java/|go-http|httpclient|` +
+	`headless|phantom|lighthouse|pingdom|uptime|monitor|feed|rss|preview|facebookexternalhit|yahoo|mediapartners`)
+
+// IsBot 根据UA判断是否为爬虫，空UA视为爬虫
+func IsBot(ua string) bool {
+	return ua == "" || botUA.MatchString(ua)
+}
+
+// 文章浏览计数，先在内存中累加，定时批量写库
+type viewCounter struct {
+	mu      sync.Mutex
+	pending map[int64]int64
+	seen    map[string]time.Time
+	order   []viewSeen // 按记录时间排列，用于清理与淘汰
+	max     int
+	window  time.Duration
+	flush   time.Duration
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newViewCounter(flush, window time.Duration) *viewCounter {
+	if flush <= 0 {
+		flush = defViewFlush
+	}
+	if window <= 0 {
+		window = defViewWindow
+	}
+	return &viewCounter{
+		pending: make(map[int64]int64),
+		seen:    make(map[string]time.Time),
+		max:     maxViewSeen,
+		window:  window,
+		flush:   flush,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// 记录一次浏览，窗口期内重复访问返回false
+func (v *viewCounter) add(id int64, visitor string, now time.Time) bool {
+	key := fmt.Sprintf("%d_%s", id, visitor)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if t, ok := v.seen[key]; ok && now.Sub(t) < v.window {
+		return false
+	}
+	v.seen[key] = now
+	v.order = append(v.order, viewSeen{key: key, at: now})
+	for len(v.seen) > v.max {
+		v.shift()
+	}
+	v.pending[id]++
+	return true
+}
+
+type viewSeen struct {
+	key string
+	at  time.Time
+}
+
+// shift 移除最早的一条记录，同一访客已重新记录时只丢弃旧的排队项
+func (v *viewCounter) shift() {
+	e := v.order[0]
+	v.order = v.order[1:]
+	if t, ok := v.seen[e.key]; ok && t.Equal(e.at) {
+		delete(v.seen, e.key)
+	}
+}
+
+func (v *viewCounter) get(id int64) int64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.pending[id]
+}
+
+// 取出待写入的计数，同时清理过期的访客记录
+func (v *viewCounter) take(now time.Time) (pending map[int64]int64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	pending = v.pending
+	v.pending = make(map[int64]int64)
+	for len(v.order) > 0 && now.Sub(v.order[0].at) >= v.window {
+		v.shift()
+	}
+	return
+}
+
+// 写库失败时归还计数，等待下次写入
+func (v *viewCounter) restore(id, n int64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.pending[id] += n
+}
+
+// AddArticleView 记录文章浏览，过滤爬虫并对同一访客去重
+func (s *service) AddArticleView(id int64, ip, ua string) (counted bool) {
+	if id <= 0 || IsBot(ua) {
+		return
+	}
+	sum := sha1.Sum([]byte(ip + "|" + ua))
+	return s.views.add(id, hex.EncodeToString(sum[:]), time.Now())
+}
+
+// 将内存中的浏览计数补到文章上，避免刚浏览完看不到变化
+func (s *service) fillViews(articles ...*model.Article) {
+	for _, article := range articles {
+		if article != nil {
+			article.Views += s.views.get(article.ID)
+		}
+	}
+}
+
+// 定时批量写入浏览次数，访问统计与跳转命中随之一并写入
+func (s *service) runViewCounter() {
+	ticker := time.NewTicker(s.views.flush)
+	defer func() {
+		ticker.Stop()
+		s.flushViews()
+		s.flushStats()
+		s.flushRedirectHits()
+		close(s.views.done)
+	}()
+	for {
+		select {
+		case <-ticker.C:
+			s.flushViews()
+			s.flushStats()
+			s.flushRedirectHits()
+		case <-s.views.stop:
+			return
+		}
+	}
+}
+
+func (s *service) flushViews() {
+	pending := s.views.take(time.Now())
+	if len(pending) == 0 {
+		return
+	}
+	for id, n := range pending {
+		err := s.db.Model(&model.Article{}).Where("id = ?", id).
+			UpdateColumn("views", gorm.Expr("views + ?", n)).Error
+		if err != nil {
+			log.Errorf("flush article(%d) views error(%v)", id, err)
+			s.views.restore(id, n)
+		}
+	}
+	// 热门文章按浏览量排序，计数变化后刷新
+	s.mc.Delete(s.hm.GetCacheKey(5))
+}
+
+// 停止定时写库并等待最后一次写入完成，可重复调用
+func (s *service) stopViewCounter() {
+	s.views.once.Do(func() { close(s.views.stop) })
+	<-s.views.done
+}
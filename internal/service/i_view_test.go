@@ -0,0 +1,47 @@
+package service
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestViewCounter(t *testing.T) {
+	Convey("viewCounter", t, func() {
+		v := newViewCounter(time.Minute, 30*time.Minute)
+		now := time.Now()
+		So(v.add(1, "a", now), ShouldBeTrue)
+		So(v.add(1, "a", now.Add(time.Minute)), ShouldBeFalse)
+		So(v.add(1, "b", now), ShouldBeTrue)
+		So(v.add(1, "a", now.Add(31*time.Minute)), ShouldBeTrue)
+		So(v.get(1), ShouldEqual, 3)
+		So(v.take(now.Add(2*time.Hour)), ShouldResemble, map[int64]int64{1: 3})
+		So(v.get(1), ShouldEqual, 0)
+		So(v.seen, ShouldBeEmpty)
+		So(v.order, ShouldBeEmpty)
+	})
+	Convey("viewCounter 去重记录有上限", t, func() {
+		v := newViewCounter(time.Minute, 30*time.Minute)
+		v.max = 2
+		now := time.Now()
+		So(v.add(1, "a", now), ShouldBeTrue)
+		So(v.add(1, "b", now.Add(time.Second)), ShouldBeTrue)
+		So(v.add(1, "c", now.Add(2*time.Second)), ShouldBeTrue)
+		So(v.seen, ShouldHaveLength, 2)
+		So(v.seen, ShouldNotContainKey, "1_a")
+		So(v.add(1, "a", now.Add(3*time.Second)), ShouldBeTrue)
+		So(v.add(1, "c", now.Add(4*time.Second)), ShouldBeFalse)
+	})
+	Convey("stopViewCounter 可重复调用", t, func() {
+		s := &service{views: newViewCounter(time.Minute, 0), stats: newStatCollector(), redirects: newRedirectHits()}
+		go s.runViewCounter()
+		So(s.stopViewCounter, ShouldNotPanic)
+		So(s.stopViewCounter, ShouldNotPanic)
+	})
+	Convey("IsBot", t, func() {
+		So(IsBot("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"), ShouldBeTrue)
+		So(IsBot("Mozilla/5.0 (compatible; Baiduspider/2.0)"), ShouldBeTrue)
+		So(IsBot(""), ShouldBeTrue)
+		So(IsBot("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/80.0 Safari/537.36"), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"crypto/md5"
+	"fmt"
+	"ginana-blog/internal/model"
+	"strconv"
+	"strings"
+)
+
+// CanViewArticle 判断用户能否查看文章全文，unlocked 表示访客已在本次会话中通过密码验证
+// 超级管理员与作者始终可见
+func (s *service) CanViewArticle(article *model.Article, userID int64, unlocked bool) bool {
+	if article.IsPublic() || userID == 1 || userID > 0 && userID == article.UserID {
+		return true
+	}
+	if article.Visibility == model.VisibilityPassword {
+		return unlocked
+	}
+	if userID <= 0 {
+		return false
+	}
+	// 登录用户与指定角色可见时，已禁用的用户即使会话未过期也不可查看
+	user, err := s.GetUser(userID)
+	if err != nil || !user.IsAuth {
+		return false
+	}
+	switch article.Visibility {
+	case model.VisibilityMember:
+		return true
+	case model.VisibilityRole:
+		for _, role := range user.Roles {
+			if article.HasRole(role.ID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const (
+	maxUnlockAttempts   = 5       // 同一IP对同一文章允许连续输错的次数
+	unlockAttemptWindow = 15 * 60 // 输错次数的统计时长，单位秒
+)
+
+// UnlockArticle 校验密码保护文章的访问密码，同一IP对同一文章连续输错过多时暂时拒绝校验
+func (s *service) UnlockArticle(id int64, password, ip string) (err error) {
+	key := s.hm.GetCacheKey(16, fmt.Sprintf("%d_%x", id, md5.Sum([]byte(ip))))
+	var attempts int
+	if s.mc.Get(key, &attempts) == nil && attempts >= maxUnlockAttempts {
+		return s.hm.GetMessage(1012)
+	}
+	article := new(model.Article)
+	if err = s.db.Select("id, visibility, password").
+		Find(article, "id = ? and status = 0", id).Error; err != nil {
+		return s.hm.GetMessage(1001, err)
+	}
+	if article.Visibility != model.VisibilityPassword || password == "" ||
+		!s.tool.BcryptHashCompare(article.Password, password) {
+		s.addUnlockAttempt(key)
+		return s.hm.GetMessage(1008)
+	}
+	s.mc.Delete(key)
+	return
+}
+
+// addUnlockAttempt 输错次数加一，首次输错时开始计时
+func (s *service) addUnlockAttempt(key string) {
+	if _, err := s.mc.Increment(key, 1); err == nil {
+		return
+	}
+	one := 1
+	if s.mc.Add(key, &one) == nil {
+		_ = s.mc.Touch(key, unlockAttemptWindow)
+	}
+}
+
+// GetSitemapArticles 站点地图中的文章，仅包含已发布的公开文章
+func (s *service) GetSitemapArticles() (articles []*model.Article, err error) {
+	if err = s.db.Select("id, urlname, urltype, updated_at").
+		Where("status = 0 and visibility = ?", model.VisibilityPublic).
+		Order("id desc").Find(&articles).Error; err != nil {
+		return nil, s.hm.GetMessage(1001, err)
+	}
+	return
+}
+
+// setArticleVisibility 保存可见性设置，密码留空时沿用原密码
+func (s *service) setArticleVisibility(article *model.Article, req *model.ArticleReq) (err error) {
+	article.Visibility = req.Visibility
+	if req.Visibility != model.VisibilityPassword {
+		article.Password = ""
+	} else if req.Password != "" {
+		article.Password = s.tool.BcryptHashGenerate(req.Password)
+	} else if article.Password == "" {
+		return s.hm.GetMessage(500, "请设置文章访问密码")
+	}
+	article.RoleIDs = ""
+	if req.Visibility == model.VisibilityRole {
+		ids := make([]string, 0, len(req.RoleIDs))
+		for _, id := range req.RoleIDs {
+			if id > 0 {
+				ids = append(ids, strconv.FormatInt(id, 10))
+			}
+		}
+		if len(ids) == 0 {
+			return s.hm.GetMessage(500, "请选择可查看文章的角色")
+		}
+		article.RoleIDs = strings.Join(ids, ",")
+	}
+	return
+}
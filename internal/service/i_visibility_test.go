@@ -0,0 +1,52 @@
+package service
+
+import (
+	"ginana-blog/internal/model"
+	. "github.com/smartystreets/goconvey/convey"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCanViewArticle(t *testing.T) {
+	Convey("CanViewArticle", t, func() {
+		s := new(service)
+		article := &model.Article{UserID: 2, Visibility: model.VisibilityPublic}
+		So(s.CanViewArticle(article, 0, false), ShouldBeTrue)
+		article.Visibility = model.VisibilityPassword
+		So(s.CanViewArticle(article, 0, false), ShouldBeFalse)
+		So(s.CanViewArticle(article, 0, true), ShouldBeTrue)
+		So(s.CanViewArticle(article, 2, false), ShouldBeTrue)
+		So(s.CanViewArticle(article, 1, false), ShouldBeTrue)
+		article.Visibility = model.VisibilityMember
+		So(s.CanViewArticle(article, 0, true), ShouldBeFalse)
+		article.Visibility = model.VisibilityRole
+		So(s.CanViewArticle(article, 0, true), ShouldBeFalse)
+	})
+	Convey("Lock", t, func() {
+		article := &model.Article{Content: "正文", HTML: "<p>正文</p>", TOC: "<ul></ul>", PlainText: "正文"}
+		article.Lock()
+		So(article.Locked, ShouldBeTrue)
+		So(article.HTML, ShouldBeEmpty)
+		So(article.Content, ShouldBeEmpty)
+		So(article.Excerpt(), ShouldEqual, "正文")
+	})
+}
+
+func TestCanViewArticleMember(t *testing.T) {
+	requireDB(t)
+	Convey("CanViewArticle 登录用户可见", t, func() {
+		suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+		newUser := func(name string, isAuth bool) *model.User {
+			user, err := svc.CreateUser(&model.CreateUserReq{
+				Username: name + suffix, Password: "123123", Nickname: name + suffix, IsAuth: isAuth,
+			})
+			So(err, ShouldBeNil)
+			return user
+		}
+		active, disabled := newUser("member", true), newUser("disabled", false)
+		article := &model.Article{UserID: 2, Visibility: model.VisibilityMember}
+		So(svc.CanViewArticle(article, active.ID, false), ShouldBeTrue)
+		So(svc.CanViewArticle(article, disabled.ID, false), ShouldBeFalse)
+	})
+}
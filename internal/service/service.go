@@ -5,16 +5,24 @@ import (
 	"fmt"
 	"ginana-blog/internal/config"
 	"ginana-blog/internal/model"
+	"ginana-blog/internal/render"
+	"ginana-blog/internal/trace"
 	"github.com/casbin/casbin/v2"
 	"github.com/griffin702/ginana/library/cache/memcache"
 	"github.com/griffin702/ginana/library/database"
 	"github.com/griffin702/service/tools"
 	"github.com/jinzhu/gorm"
+	"time"
 )
 
 type Service interface {
 	Close()
 	SetEnforcer(ef *casbin.SyncedEnforcer) (err error)
+	WithRequestID(id string) Service
+
+	// 安装
+	NeedSetup() bool
+	Setup(req *model.SetupReq) (user *model.User, err error)
 
 	// 公共
 	GetCaptcha() (res *model.Captcha, err error)
@@ -65,10 +73,52 @@ type Service interface {
 	BatchArticle(req *model.ArticleListReq) (err error)
 	PushBaiDu(url string) (string, error)
 	CountArticles() (count int64)
+	RenderArticle(article *model.Article) (err error)
+	GetRelatedArticles(id int64, limit int) (articles []*model.Article, err error)
+	AddArticleView(id int64, ip, ua string) (counted bool)
+	CanViewArticle(article *model.Article, userID int64, unlocked bool) bool
+	UnlockArticle(id int64, password, ip string) (err error)
+	ArticleSlug(title, urlname string, urltype int8, id int64) (slug string, err error)
+	GetSitemapArticles() (articles []*model.Article, err error)
+
+	// 分类
+	GetCategoryTree() (tree *model.CategoryTree, err error)
+	GetCategory(id int64) (category *model.Category, err error)
+	GetCategoryBySlug(slug string) (category *model.Category, err error)
+	CreateCategory(req *model.CreateCategoryReq) (category *model.Category, err error)
+	UpdateCategory(req *model.UpdateCategoryReq) (category *model.Category, err error)
+	DeleteCategory(id int64) (err error)
+	SortCategories(nodes []*model.CategorySortReq) (err error)
+
+	// 归档
+	GetArchives() (years []*model.ArchiveYear, err error)
+	GetCalendar(year, month int) (calendar *model.Calendar, err error)
+
+	// 系列
+	GetSeriesList(p *model.Pager) (res *model.SeriesList, err error)
+	GetSeries(id int64) (series *model.Series, err error)
+	GetPublicSeries(id int64) (series *model.Series, err error)
+	CreateSeries(req *model.CreateSeriesReq) (series *model.Series, err error)
+	UpdateSeries(req *model.UpdateSeriesReq) (series *model.Series, err error)
+	DeleteSeries(id int64) (err error)
+	GetArticleTitles() (articles []*model.Article, err error)
+
+	// 点赞与表情回应
+	GetArticleReactions(articleID, userID int64, visitorID, ip string) (list []*model.ReactionCount, err error)
+	ToggleReaction(req *model.ReactionReq) (list []*model.ReactionCount, err error)
+	GetLikedArticles(limit int) (articles []*model.Article, err error)
+
+	// 访问统计
+	RecordHit(hit *model.StatHit)
+	GetStatReport(pr model.StatQueryParam) (res *model.StatReport, err error)
 
 	// 标签
 	GetTags(p *model.Pager, prs ...model.TagQueryParam) (res *model.Tags, err error)
+	GetTag(id int64) (tag *model.Tag, err error)
 	GetTagByName(name string) (tag *model.Tag, err error)
+	ResolveTag(key string) (tag *model.Tag, err error)
+	ResolveTagNames(names string) (list []string)
+	UpdateTag(req *model.UpdateTagReq) (tag *model.Tag, err error)
 	BatchTag(req *model.TagListReq) (err error)
 	CountTags() (count int64)
 	GetTagsLimit6() (tags []*model.Tag, err error)
@@ -101,38 +151,89 @@ type Service interface {
 	SetAlbumStatus(id int64, hidden bool) (err error)
 	SetAlbumCover(id int64, cover string) (err error)
 
+	// 跳转
+	GetRedirects(p *model.Pager) (res *model.Redirects, err error)
+	GetRedirect(id int64) (redirect *model.Redirect, err error)
+	CreateRedirect(req *model.CreateRedirectReq) (redirect *model.Redirect, err error)
+	UpdateRedirect(req *model.UpdateRedirectReq) (redirect *model.Redirect, err error)
+	DeleteRedirect(id int64) (err error)
+	MatchRedirect(path string) (target string, code int, ok bool)
+
+	// 导入导出
+	ImportContent(data *model.ImportData, dryRun bool) (report *model.ImportReport, err error)
+	GetExportData() (data *model.ExportData, err error)
+
+	// 备份恢复
+	BackupTables() (tables []*model.BackupTable, err error)
+	RestoreTables(tables []*model.BackupTable) (err error)
+
+	// 运维
+	ResetPassword(username, password string) (user *model.User, err error)
+	SetUserAuth(username string, isAuth bool) (user *model.User, err error)
+	GrantRole(username, roleName string) (err error)
+	SyncPolices(polices []*model.Policy) (created []*model.Policy, err error)
+	FlushCache() (err error)
+	Migrate() (tables []string, err error)
+	FixDuplicateSlugs() (renamed []*model.Article, err error)
+	Reindex() (count int, err error)
+	CheckReady() (checks map[string]error)
+
 	// 照片
 	GetPhotos(p *model.Pager, albumId int64) (res *model.Photos, err error)
+	GetPhoto(id int64) (photo *model.Photo, err error)
 	CreatePhoto(req *model.CreatePhotoReq) (photo *model.Photo, err error)
 	UpdatePhoto(req *model.UpdatePhotoReq) (photo *model.Photo, err error)
 	DeletePhoto(id int64) (err error)
 }
 
 func New(cfg *config.Config, db *gorm.DB, mc memcache.Memcache, hm HelperMap) (s Service, err error) {
-	s = &service{
+	svc := &service{
 		cfg:  cfg,
 		db:   db,
 		mc:   mc,
 		hm:   hm,
 		tool: tools.Tools,
 	}
-	_, err = s.GetSiteOptions()
-	return
+	svc.installed = new(int32)
+	svc.scs = svc.newShortcodes()
+	svc.views = newViewCounter(time.Duration(cfg.ViewFlush), time.Duration(cfg.ViewWindow))
+	svc.stats = newStatCollector()
+	svc.redirects = newRedirectHits()
+	if _, err = svc.GetSiteOptions(); err != nil {
+		return
+	}
+	// 最后一步可能出错的调用之后再启动，避免出错返回时协程泄漏
+	go svc.runViewCounter()
+	return svc, nil
 }
 
 type service struct {
-	cfg  *config.Config
-	db   *gorm.DB
-	ef   *casbin.SyncedEnforcer
-	mc   memcache.Memcache
-	hm   HelperMap
-	tool *tools.Tool
+	cfg       *config.Config
+	db        *gorm.DB
+	ef        *casbin.SyncedEnforcer
+	mc        memcache.Memcache
+	hm        HelperMap
+	tool      *tools.Tool
+	scs       *render.Shortcodes
+	views     *viewCounter
+	stats     *statCollector
+	redirects *redirectHits
+	installed *int32 // 已创建管理员，指针使 WithRequestID 的副本共享该状态
 }
 
 func (s *service) Close() {
+	s.stopViewCounter()
 	_ = s.db.Close()
 }
 
+// WithRequestID 返回带请求ID的服务副本，经该副本执行的数据库操作在日志中带上请求ID
+func (s *service) WithRequestID(id string) Service {
+	if id == "" {
+		return s
+	}
+	return s.withDB(s.db.Set(trace.GormKey, id))
+}
+
 // Close close the resource.
 func (s *service) SetEnforcer(ef *casbin.SyncedEnforcer) (err error) {
 	if !s.cfg.Casbin.Enable {
@@ -6,6 +6,7 @@ import (
 	"github.com/griffin702/ginana/library/cache/memcache"
 	"github.com/griffin702/ginana/library/database"
 	"github.com/jinzhu/gorm"
+	"log"
 	"os"
 	"testing"
 )
@@ -23,7 +24,9 @@ func TestMain(m *testing.M) {
 	mc := memcache.New(cfg.Memcache)
 	mysql, err := database.NewMySQL(cfg.MySQL)
 	if err != nil {
-		panic(err)
+		// 没有测试数据库时只运行不依赖数据库的测试
+		log.Printf("skip database tests: %v", err)
+		os.Exit(m.Run())
 	}
 	initTable(mysql)
 	err = initTableData(mysql, mc)
@@ -44,20 +47,15 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+// requireDB 没有测试数据库时跳过当前测试
+func requireDB(t *testing.T) {
+	if svc == nil {
+		t.Skip("test database is not available")
+	}
+}
+
 func initTable(db *gorm.DB) {
-	db.AutoMigrate(
-		new(model.Options),
-		new(model.User),
-		new(model.Role),
-		new(model.Policy),
-		new(model.Article),
-		new(model.Tag),
-		new(model.Mood),
-		new(model.Link),
-		new(model.Comment),
-		new(model.Album),
-		new(model.Photo),
-	)
+	db.AutoMigrate(model.Tables...)
 }
 
 func initTableData(db *gorm.DB, mc memcache.Memcache) (err error) {
@@ -75,6 +73,8 @@ func initTableData(db *gorm.DB, mc memcache.Memcache) (err error) {
 		options["WeiBo"] = "https://weibo.com/p/1005051484763434"
 		options["Github"] = "https://github.com/griffin702"
 		options["AlbumSize"] = "9"
+		options["RelatedSize"] = "5"
+		options["Reactions"] = model.DefReactions
 		options["Nickname"] = "云丶先生|Nana"
 		options["MyOldCity"] = "湖北省 黄石市"
 		options["MyCity"] = "湖北省 武汉市"
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"ginana-blog/internal/model"
+	"ginana-blog/internal/render"
+	"html/template"
+	"regexp"
+)
+
+var (
+	scTemplate = template.Must(template.New("shortcode").Parse(`
+{{- define "album" -}}
+<div class="sc-album">
+<p class="sc-album-title"><a href="/album/{{.Album.ID}}">{{.Album.Name}}</a></p>
+<ul class="sc-album-list list-unstyled" id="sc-album-{{.Album.ID}}">
+{{- range .Photos}}<li data-src="{{.Url}}" data-sub-html="{{.Desc}}"><a href="#"><img src="{{.ChangetoSmall}}" alt="{{.Desc}}" loading="lazy"></a></li>{{end -}}
+</ul>
+</div>
+{{- end}}
+{{- define "photo" -}}
+<figure class="sc-photo"><a href="{{.Src}}" target="_blank"><img src="{{.Src}}" alt="{{.Caption}}" loading="lazy"></a>
+{{- if .Caption}}<figcaption>{{.Caption}}</figcaption>{{end}}</figure>
+{{- end}}
+{{- define "video" -}}
+<div class="sc-video">
+{{- if .Embed}}<iframe src="{{.Embed}}" allowfullscreen="true" frameborder="0" scrolling="no"></iframe>
+{{- else}}<video src="{{.Src}}"{{if .Poster}} poster="{{.Poster}}"{{end}} controls preload="metadata"></video>{{end -}}
+</div>
+{{- end}}
+{{- define "note" -}}
+<div class="sc-note sc-note-{{.Type}}">{{.Inner}}</div>
+{{- end}}
+{{- define "article" -}}
+<a class="sc-article" href="{{.Link}}">
+{{- if .Cover}}<img src="{{.Cover}}" alt="{{.Title}}" loading="lazy">{{end -}}
+<span class="sc-article-title">{{.Title}}</span><span class="sc-article-desc">{{.Excerpt}}</span></a>
+{{- end}}`))
+
+	noteTypes = map[string]bool{"info": true, "warn": true, "danger": true, "success": true}
+	videoIDs  = regexp.MustCompile(`^[\w-]+$`)
+)
+
+// 注册文章中可用的短代码
+func (s *service) newShortcodes() *render.Shortcodes {
+	scs := render.NewShortcodes()
+	scs.Register("album", s.albumShortcode)
+	scs.Register("photo", s.photoShortcode)
+	scs.Register("video", s.videoShortcode)
+	scs.Register("note", s.noteShortcode)
+	scs.Register("article", s.articleShortcode)
+	return scs
+}
+
+func executeShortcode(name string, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := scTemplate.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// {{< album id=3 limit=9 >}}
+func (s *service) albumShortcode(sc *render.Shortcode) (string, error) {
+	album, err := s.GetAlbum(sc.Int("id", 0))
+	if err != nil {
+		return "", err
+	}
+	if album.Hidden {
+		return "", fmt.Errorf("相册 %d 已隐藏", album.ID)
+	}
+	p := new(model.Pager)
+	p.Page = 1
+	p.PageSize = sc.Int("limit", 9)
+	photos, err := s.GetPhotos(p, album.ID)
+	if err != nil {
+		return "", err
+	}
+	return executeShortcode("album", map[string]interface{}{
+		"Album":  album,
+		"Photos": photos.List,
+	})
+}
+
+// {{< photo id=5 >}} 或 {{< photo src="/static/upload/a.jpg" caption="说明" >}}
+func (s *service) photoShortcode(sc *render.Shortcode) (string, error) {
+	data := map[string]string{
+		"Src":     sc.Get("src", ""),
+		"Caption": sc.Get("caption", ""),
+	}
+	if id := sc.Int("id", 0); id > 0 {
+		photo, err := s.GetPhoto(id)
+		if err != nil {
+			return "", err
+		}
+		album, err := s.GetAlbum(photo.AlbumID)
+		if err != nil {
+			return "", err
+		}
+		if album.Hidden {
+			return "", fmt.Errorf("照片 %d 所在的相册已隐藏", photo.ID)
+		}
+		data["Src"] = photo.Url
+		data["Caption"] = sc.Get("caption", photo.Desc)
+	}
+	if data["Src"] == "" {
+		return "", fmt.Errorf("缺少参数 src 或 id")
+	}
+	return executeShortcode("photo", data)
+}
+
+// {{< video src="/static/upload/a.mp4" poster="..." >}}、{{< video bilibili="BV..." >}}、{{< video youtube="..." >}}
+func (s *service) videoShortcode(sc *render.Shortcode) (string, error) {
+	data := map[string]interface{}{
+		"Src":    sc.Get("src", ""),
+		"Poster": sc.Get("poster", ""),
+	}
+	if id := sc.Get("bilibili", ""); id != "" {
+		if !videoIDs.MatchString(id) {
+			return "", fmt.Errorf("无效的视频ID")
+		}
+		data["Embed"] = template.URL("https://player.bilibili.com/player.html?high_quality=1&bvid=" + id)
+	} else if id := sc.Get("youtube", ""); id != "" {
+		if !videoIDs.MatchString(id) {
+			return "", fmt.Errorf("无效的视频ID")
+		}
+		data["Embed"] = template.URL("https://www.youtube-nocookie.com/embed/" + id)
+	} else if data["Src"] == "" {
+		return "", fmt.Errorf("缺少参数 src")
+	}
+	return executeShortcode("video", data)
+}
+
+// {{< note type=warn >}}内容{{< /note >}}
+func (s *service) noteShortcode(sc *render.Shortcode) (string, error) {
+	typ := sc.Get("type", "info")
+	if !noteTypes[typ] {
+		typ = "info"
+	}
+	return executeShortcode("note", map[string]interface{}{
+		"Type":  typ,
+		"Inner": template.HTML(sc.Inner),
+	})
+}
+
+// {{< article id=12 >}} 渲染为文章链接卡片
+func (s *service) articleShortcode(sc *render.Shortcode) (string, error) {
+	// 不经过GetArticle，避免文章互相引用时递归渲染；非公开文章不展示摘要
+	article := new(model.Article)
+	if err := s.db.Find(article, "id = ? and status = 0 and visibility = ?",
+		sc.Int("id", 0), model.VisibilityPublic).Error; err != nil {
+		return "", err
+	}
+	return executeShortcode("article", map[string]interface{}{
+		"Link":    article.Link(),
+		"Cover":   article.Cover,
+		"Title":   article.Title,
+		"Excerpt": article.Excerpt(),
+	})
+}
@@ -0,0 +1,137 @@
+// Package trace 请求ID与结构化日志：每个请求带有通过 X-Request-ID 传入或新生成的ID，
+// 访问日志、数据库日志与错误日志以 JSON 行输出并带上该ID，便于串联一次请求的全部记录
+package trace
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/griffin702/ginana/library/log"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// Header 传递请求ID的请求头与响应头
+	Header = "X-Request-ID"
+	// GormKey 请求ID在 gorm.DB 上的设置名，供数据库回调读取
+	GormKey = "trace:request_id"
+)
+
+type ctxKey struct{}
+
+// NewID 生成随机的请求ID
+func NewID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ValidID 外部传入的请求ID只接受不超过64位的字母、数字与 -_.:
+func ValidID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WithID 在 context 中保存请求ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// ID 取出 context 中的请求ID，没有时为空
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Fields 日志字段
+type Fields map[string]interface{}
+
+var (
+	mu  sync.Mutex
+	out io.Writer
+	now = time.Now
+)
+
+// SetOutput 设置日志输出，未设置时与 log 包的输出一致
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	out = w
+	mu.Unlock()
+}
+
+func Info(msg string, fields Fields) {
+	write("info", msg, fields)
+}
+
+func Warn(msg string, fields Fields) {
+	write("warn", msg, fields)
+}
+
+func Error(msg string, fields Fields) {
+	write("error", msg, fields)
+}
+
+// write 输出一行 JSON，time、level、msg 在前，其余字段按名称排序，空的请求ID不输出
+func write(level, msg string, fields Fields) {
+	var buf bytes.Buffer
+	buf.WriteString(`{"time":`)
+	appendJSON(&buf, now().Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	appendJSON(&buf, level)
+	buf.WriteString(`,"msg":`)
+	appendJSON(&buf, msg)
+	keys := make([]string, 0, len(fields))
+	for k, v := range fields {
+		if k == "request_id" && v == "" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		buf.WriteByte(',')
+		appendJSON(&buf, k)
+		buf.WriteByte(':')
+		v := fields[k]
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		appendJSON(&buf, v)
+	}
+	buf.WriteString("}\n")
+	mu.Lock()
+	defer mu.Unlock()
+	if out == nil {
+		if l := log.GetLogger(); l != nil {
+			out = l.GetOutFile()
+		}
+		if out == nil {
+			out = os.Stdout
+		}
+	}
+	_, _ = out.Write(buf.Bytes())
+}
+
+func appendJSON(buf *bytes.Buffer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(err.Error())
+	}
+	buf.Write(b)
+}
@@ -0,0 +1,56 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidID(t *testing.T) {
+	Convey("ValidID", t, func() {
+		So(ValidID(NewID()), ShouldBeTrue)
+		So(ValidID("req-1_a.b:c"), ShouldBeTrue)
+		So(ValidID(""), ShouldBeFalse)
+		So(ValidID(strings.Repeat("a", 65)), ShouldBeFalse)
+		So(ValidID("a b"), ShouldBeFalse)
+		So(ValidID("a\nb"), ShouldBeFalse)
+	})
+}
+
+func TestID(t *testing.T) {
+	Convey("ID", t, func() {
+		So(ID(context.Background()), ShouldEqual, "")
+		So(ID(WithID(context.Background(), "abc")), ShouldEqual, "abc")
+		So(NewID(), ShouldNotEqual, NewID())
+	})
+}
+
+func TestWrite(t *testing.T) {
+	Convey("Write", t, func() {
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		now = func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) }
+		defer func() {
+			SetOutput(nil)
+			now = time.Now
+		}()
+
+		Error("db", Fields{"request_id": "abc", "sql": "SELECT 1", "error": errors.New("bad")})
+		So(buf.String(), ShouldEqual,
+			`{"time":"2020-01-02T03:04:05Z","level":"error","msg":"db","error":"bad","request_id":"abc","sql":"SELECT 1"}`+"\n")
+
+		buf.Reset()
+		Info("request", Fields{"request_id": "", "status": 200})
+		var m map[string]interface{}
+		So(json.Unmarshal(buf.Bytes(), &m), ShouldBeNil)
+		So(m["level"], ShouldEqual, "info")
+		So(m["status"], ShouldEqual, 200)
+		_, ok := m["request_id"]
+		So(ok, ShouldBeFalse)
+	})
+}
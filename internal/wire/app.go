@@ -31,3 +31,8 @@ func NewApp(server *http.Server, svc service.Service, ef *casbin.SyncedEnforcer)
 	}
 	return
 }
+
+// Service 供命令行工具直接调用业务方法
+func (app *App) Service() service.Service {
+	return app.svc
+}